@@ -0,0 +1,222 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// The binary encoding of CPU sets, as used by [List.MarshalBinary] and
+// [Set.MarshalBinary], is stable across releases and consists of:
+//
+//   - a single version byte, currently binaryVersion1,
+//   - a single byte telling the encoding kind, either binaryRanges or
+//     binaryWords,
+//   - the number of ranges or words, as an unsigned varint,
+//   - for binaryRanges: each range as a pair of unsigned varints, with the
+//     first telling the gap since the end of the preceding range (or since CPU
+//     0 for the first range), and the second telling the range's size minus
+//     one. Ranges are in ascending order and neither overlap nor adjoin.
+//   - for binaryWords: the Set words, each as 64 bits in little endian order,
+//     without any trailing zero words.
+//
+// When encoding, the shorter of both encoding kinds is used, preferring ranges.
+const (
+	binaryVersion1 = 1
+
+	binaryRanges = 0
+	binaryWords  = 1
+)
+
+var (
+	_ encoding.BinaryMarshaler   = List{}
+	_ encoding.BinaryUnmarshaler = (*List)(nil)
+	_ encoding.BinaryMarshaler   = Set{}
+	_ encoding.BinaryUnmarshaler = (*Set)(nil)
+)
+
+// MarshalBinary returns the CPUs in this List in a compact binary encoding that
+// is stable across releases. Lists not in canonical form are normalized first.
+func (l List) MarshalBinary() ([]byte, error) {
+	return l.AppendBinary(nil)
+}
+
+// AppendBinary appends the CPUs in this List in the binary encoding of
+// [List.MarshalBinary] to dst, returning the extended buffer.
+func (l List) AppendBinary(dst []byte) ([]byte, error) {
+	if !l.IsCanonical() {
+		l = l.Normalize()
+	}
+	return appendBinary(dst, l), nil
+}
+
+// UnmarshalBinary sets this List to the CPUs from the specified data in the
+// binary encoding of [List.MarshalBinary]. If the data is invalid, then
+// [ErrInvalidEncoding] is returned instead and this List is left unchanged.
+func (l *List) UnmarshalBinary(data []byte) error {
+	words, ranges, err := decodeBinary(data)
+	if err != nil {
+		return err
+	}
+	if words != nil {
+		ranges = words.List()
+	}
+	*l = ranges
+	return nil
+}
+
+// MarshalBinary returns the CPUs in this Set in a compact binary encoding that
+// is stable across releases. The encoding is the same as for
+// [List.MarshalBinary].
+func (s Set) MarshalBinary() ([]byte, error) {
+	return s.AppendBinary(nil)
+}
+
+// AppendBinary appends the CPUs in this Set in the binary encoding of
+// [Set.MarshalBinary] to dst, returning the extended buffer.
+func (s Set) AppendBinary(dst []byte) ([]byte, error) {
+	return appendBinary(dst, s.Compact()), nil
+}
+
+// UnmarshalBinary sets this Set to the CPUs from the specified data in the
+// binary encoding of [Set.MarshalBinary]. If the data is invalid, then
+// [ErrInvalidEncoding] is returned instead and this Set is left unchanged.
+func (s *Set) UnmarshalBinary(data []byte) error {
+	words, ranges, err := decodeBinary(data)
+	if err != nil {
+		return err
+	}
+	if words == nil {
+		words = Set{}
+		if last := len(ranges) - 1; last >= 0 {
+			if ranges[last][1] > maxSetCPU {
+				return fmt.Errorf("%w: Set holds only %d CPUs, found CPU %d",
+					ErrBeyondCapacity, maxSetCPU+1, ranges[last][1])
+			}
+			words = make(Set, setBitIndex(ranges[last][1])+1)
+		}
+		for _, r := range ranges {
+			words.fillRange(r[0], r[1])
+		}
+	}
+	*s = words
+	return nil
+}
+
+// appendBinary appends the specified CPUs in binary encoding to dst, choosing
+// the shorter encoding kind. The CPUs must not have any trailing zero words
+// when being a Set.
+func appendBinary(dst []byte, cpus CPUs) []byte {
+	// Determine the sizes of both encoding kinds first, without allocating.
+	var count, words, rangesSize int
+	next := uint(0)
+	for from, to := range cpus.Ranges() {
+		rangesSize += uvarintLen(uint64(from-next)) + uvarintLen(uint64(to-from))
+		next = to + 1
+		count++
+		words = setBitIndex(to) + 1
+	}
+	dst = append(dst, binaryVersion1)
+	if words*int(wordbytesize)+uvarintLen(uint64(words)) < rangesSize+uvarintLen(uint64(count)) {
+		dst = append(dst, binaryWords)
+		dst = binary.AppendUvarint(dst, uint64(words))
+		for _, word := range cpus.Set()[:words] {
+			dst = binary.LittleEndian.AppendUint64(dst, word)
+		}
+		return dst
+	}
+	dst = append(dst, binaryRanges)
+	dst = binary.AppendUvarint(dst, uint64(count))
+	next = 0
+	for from, to := range cpus.Ranges() {
+		dst = binary.AppendUvarint(dst, uint64(from-next))
+		dst = binary.AppendUvarint(dst, uint64(to-from))
+		next = to + 1
+	}
+	return dst
+}
+
+// uvarintLen returns the number of bytes of the specified unsigned varint.
+func uvarintLen(x uint64) int {
+	return max(1, (bits.Len64(x)+6)/7)
+}
+
+// decodeBinary decodes the specified data in binary encoding, returning either
+// the encoded Set words or the encoded ranges.
+func decodeBinary(data []byte) (words Set, ranges List, err error) {
+	if len(data) < 2 {
+		return nil, nil, fmt.Errorf("%w: too short", ErrInvalidEncoding)
+	}
+	if data[0] != binaryVersion1 {
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidEncoding, data[0])
+	}
+	kind := data[1]
+	count, n := binary.Uvarint(data[2:])
+	if n <= 0 {
+		return nil, nil, fmt.Errorf("%w: invalid count", ErrInvalidEncoding)
+	}
+	data = data[2+n:]
+	switch kind {
+	case binaryWords:
+		// Never trust the count, but only allocate as much as there is data.
+		if count > uint64(len(data))/wordbytesize || count*wordbytesize != uint64(len(data)) {
+			return nil, nil, fmt.Errorf("%w: expected %d words, found %d bytes",
+				ErrInvalidEncoding, count, len(data))
+		}
+		words = make(Set, count)
+		for idx := range words {
+			words[idx] = binary.LittleEndian.Uint64(data[idx*int(wordbytesize):])
+		}
+		return words, nil, nil
+	case binaryRanges:
+		// Each range takes at least two bytes.
+		if count > uint64(len(data))/2 {
+			return nil, nil, fmt.Errorf("%w: expected %d ranges, found only %d bytes",
+				ErrInvalidEncoding, count, len(data))
+		}
+		ranges = make(List, 0, count)
+		next := uint(0)
+		for range count {
+			gap, n := binary.Uvarint(data)
+			if n <= 0 {
+				return nil, nil, fmt.Errorf("%w: invalid range gap", ErrInvalidEncoding)
+			}
+			data = data[n:]
+			size, n := binary.Uvarint(data)
+			if n <= 0 {
+				return nil, nil, fmt.Errorf("%w: invalid range size", ErrInvalidEncoding)
+			}
+			data = data[n:]
+			from := next + uint(gap)
+			to := from + uint(size)
+			if len(ranges) > 0 && gap == 0 {
+				return nil, nil, fmt.Errorf("%w: adjoining ranges", ErrInvalidEncoding)
+			}
+			if (len(ranges) > 0 && next == 0) || from < next || to < from {
+				return nil, nil, fmt.Errorf("%w: range beyond CPU number space", ErrInvalidEncoding)
+			}
+			ranges = append(ranges, [2]uint{from, to})
+			next = to + 1
+		}
+		if len(data) != 0 {
+			return nil, nil, fmt.Errorf("%w: %d trailing bytes", ErrInvalidEncoding, len(data))
+		}
+		return nil, ranges, nil
+	}
+	return nil, nil, fmt.Errorf("%w: unknown kind %d", ErrInvalidEncoding, kind)
+}
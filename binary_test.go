@@ -0,0 +1,134 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("binary encoding", func() {
+
+	// These encodings must never change, as they are persisted and exchanged.
+	DescribeTable("encoding stably",
+		func(s Set, expected []byte) {
+			Expect(s.MarshalBinary()).To(Equal(expected))
+			Expect(s.List().MarshalBinary()).To(Equal(expected))
+		},
+		Entry("empty", Set{}, []byte{1, 0, 0}),
+		Entry("trailing zero words", Set{0, 0}, []byte{1, 0, 0}),
+		Entry("ranges", Set{0x13c}, []byte{1, 0, 2, 2, 3, 2, 0}),
+		Entry("long range", Set{^uint64(0), ^uint64(0)}, []byte{1, 0, 1, 0, 127}),
+		Entry("dense words", Set{0x5555555555555555},
+			[]byte{1, 1, 1, 0x55, 0x55, 0x55, 0x55, 0x55, 0x55, 0x55, 0x55}),
+	)
+
+	DescribeTable("round-tripping",
+		func(text string) {
+			l := Successful(NewList([]byte(text)))
+
+			var l2 List
+			Expect(l2.UnmarshalBinary(Successful(l.MarshalBinary()))).To(Succeed())
+			Expect(l2).To(Equal(l))
+			var s Set
+			Expect(s.UnmarshalBinary(Successful(l.Set().MarshalBinary()))).To(Succeed())
+			Expect(s.List()).To(Equal(l))
+		},
+		Entry(nil, ""),
+		Entry(nil, "0"),
+		Entry(nil, "2-5,8,511"),
+		Entry(nil, "0-8191"),
+		Entry(nil, "0,2,4,6,8,10,12,14,16,18,20,22,24,26,28,30,65,67,69,71,73,75"),
+	)
+
+	It("round-trips the full CPU number space", func() {
+		maxcpu := ^uint(0)
+		for _, l := range []List{{{0, maxcpu}}, {{1, 2}, {maxcpu, maxcpu}}} {
+			var l2 List
+			Expect(l2.UnmarshalBinary(Successful(l.MarshalBinary()))).To(Succeed())
+			Expect(l2).To(Equal(l))
+		}
+	})
+
+	It("prefers the shorter encoding", func() {
+		sparse := Set{}
+		sparse.Add(0)
+		sparse.Add(511)
+		Expect(Successful(sparse.MarshalBinary())).To(HaveLen(8))
+
+		dense := Collect(func(yield func(uint) bool) {
+			for cpu := uint(0); cpu < 512; cpu += 2 {
+				yield(cpu)
+			}
+		})
+		Expect(Successful(dense.MarshalBinary())).To(HaveLen(3 + 8*8))
+	})
+
+	It("normalizes non-canonical lists", func() {
+		Expect(List{{5, 8}, {1, 3}, {4, 4}}.MarshalBinary()).To(Equal([]byte{1, 0, 1, 1, 7}))
+	})
+
+	It("appends", func() {
+		b := Successful(Set{0x13c}.AppendBinary([]byte("foo")))
+		Expect(bytes.HasPrefix(b, []byte("foo"))).To(BeTrue())
+		var s Set
+		Expect(s.UnmarshalBinary(b[3:])).To(Succeed())
+		Expect(s.String()).To(Equal("2-5,8"))
+		Expect(List{{2, 5}, {8, 8}}.AppendBinary([]byte("foo"))).To(Equal(b))
+	})
+
+	DescribeTable("rejecting invalid data, leaving the destination unchanged",
+		func(data []byte) {
+			l := List{{42, 42}}
+			Expect(l.UnmarshalBinary(data)).To(MatchError(ErrInvalidEncoding))
+			Expect(l).To(Equal(List{{42, 42}}))
+			s := Set{0x1}
+			Expect(s.UnmarshalBinary(data)).To(MatchError(ErrInvalidEncoding))
+			Expect(s).To(Equal(Set{0x1}))
+		},
+		Entry("empty", []byte{}),
+		Entry("too short", []byte{1}),
+		Entry("unknown version", []byte{2, 0, 0}),
+		Entry("unknown kind", []byte{1, 2, 0}),
+		Entry("missing count", []byte{1, 0}),
+		Entry("invalid count", []byte{1, 0, 0xff}),
+		Entry("missing words", []byte{1, 1, 1, 0x55}),
+		Entry("excessive word count", []byte{1, 1, 0xff, 0xff, 0xff, 0xff, 0x0f}),
+		Entry("trailing word bytes", []byte{1, 1, 0, 0x55}),
+		Entry("missing ranges", []byte{1, 0, 2, 0, 0}),
+		Entry("excessive range count", []byte{1, 0, 0xff, 0xff, 0xff, 0xff, 0x0f, 0, 0}),
+		Entry("truncated range", []byte{1, 0, 1, 0, 0x80}),
+		Entry("adjoining ranges", []byte{1, 0, 2, 0, 0, 0, 0}),
+		Entry("trailing range bytes", []byte{1, 0, 1, 0, 0, 0}),
+		Entry("range overflow", []byte{1, 0, 1,
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01,
+			0x01}),
+		Entry("range after end", []byte{1, 0, 2,
+			0x00,
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01,
+			0x01, 0x00}),
+	)
+
+	It("refuses absurdly large sets", func() {
+		var s Set
+		Expect(s.UnmarshalBinary(Successful(List{{maxSetCPU + 1, maxSetCPU + 1}}.MarshalBinary()))).To(
+			MatchError(ErrBeyondCapacity))
+	})
+
+})
@@ -0,0 +1,238 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Cache types, as reported by the kernel.
+const (
+	DataCache        = "Data"
+	InstructionCache = "Instruction"
+	UnifiedCache     = "Unified"
+)
+
+// Cache describes a single cache instance, which might be shared by multiple
+// CPUs.
+type Cache struct {
+	Level int    // cache level, starting with 1.
+	Type  string // [DataCache], [InstructionCache], or [UnifiedCache].
+	Size  uint64 // size in bytes.
+	// ID identifies this cache instance among the caches of the same level and
+	// type; it is -1 if the kernel doesn't report cache IDs.
+	ID int
+	// SharedCPUs are the CPUs sharing this cache instance.
+	SharedCPUs List
+}
+
+// Caches are the cache instances of the online CPUs of a system, where
+// instances shared by multiple CPUs appear only once.
+type Caches struct {
+	// CPUs are the online CPUs the caches were discovered from.
+	CPUs List
+	// Caches are the cache instances, in ascending order of their levels,
+	// types, and the lowest CPUs sharing them.
+	Caches []Cache
+}
+
+// Caches returns the cache instances of the online CPUs of the system.
+// Otherwise, it returns an error.
+func (fs Sysfs) Caches() (*Caches, error) {
+	online, err := fs.OnlineCPUs()
+	if err != nil {
+		return nil, err
+	}
+	caches := &Caches{CPUs: online}
+	seen := map[string]struct{}{}
+	for cpu := range online.All() {
+		cpucaches, err := fs.CPUCaches(cpu)
+		if err != nil {
+			return nil, err
+		}
+		for _, cache := range cpucaches {
+			key := strconv.Itoa(cache.Level) + cache.Type + cache.SharedCPUs.String()
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			caches.Caches = append(caches.Caches, cache)
+		}
+	}
+	slices.SortFunc(caches.Caches, func(a, b Cache) int {
+		if c := cmp.Compare(a.Level, b.Level); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Type, b.Type); c != 0 {
+			return c
+		}
+		amin, _ := a.SharedCPUs.Min()
+		bmin, _ := b.SharedCPUs.Min()
+		return cmp.Compare(amin, bmin)
+	})
+	return caches, nil
+}
+
+// CPUCaches returns the caches of the specified CPU, in the order reported by
+// the kernel. CPUs without any cache information, such as on some virtualized
+// or embedded systems, have no caches.
+func (fs Sysfs) CPUCaches(cpu uint) ([]Cache, error) {
+	dir := filepath.Join(cpuDir(cpu), "cache")
+	entries, err := os.ReadDir(fs.path(dir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var caches []Cache
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "index") {
+			continue
+		}
+		cache, err := fs.readCache(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		caches = append(caches, *cache)
+	}
+	return caches, nil
+}
+
+// readCache returns the cache described in the specified cache index
+// directory.
+func (fs Sysfs) readCache(dir string) (*Cache, error) {
+	var cache Cache
+	var err error
+	if cache.Level, err = fs.readInt(dir, "level"); err != nil {
+		return nil, err
+	}
+	if cache.Type, err = fs.readString(dir, "type"); err != nil {
+		return nil, err
+	}
+	if cache.SharedCPUs, err = fs.readList(dir, "shared_cpu_list"); err != nil {
+		return nil, err
+	}
+	if cache.ID, err = fs.readInt(dir, "id"); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		cache.ID = -1
+	}
+	size, err := fs.readString(dir, "size")
+	if err != nil {
+		// Some architectures don't report cache sizes.
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return &cache, nil
+	}
+	if cache.Size, err = parseCacheSize(size); err != nil {
+		return nil, fmt.Errorf("invalid cache size in %q, %w", fs.path(dir, "size"), err)
+	}
+	return &cache, nil
+}
+
+// parseCacheSize returns the size in bytes of a cache size such as “48K”.
+func parseCacheSize(size string) (uint64, error) {
+	shift := 0
+	switch {
+	case strings.HasSuffix(size, "K"):
+		shift = 10
+	case strings.HasSuffix(size, "M"):
+		shift = 20
+	case strings.HasSuffix(size, "G"):
+		shift = 30
+	}
+	if shift != 0 {
+		size = size[:len(size)-1]
+	}
+	num, err := strconv.ParseUint(size, 10, 64-shift)
+	if err != nil {
+		return 0, err
+	}
+	return num << shift, nil
+}
+
+// OfCPU returns the cache instances of the specified CPU, in ascending order of
+// their levels and types.
+func (c *Caches) OfCPU(cpu uint) []Cache {
+	var caches []Cache
+	for _, cache := range c.Caches {
+		if cache.SharedCPUs.Contains(cpu) {
+			caches = append(caches, cache)
+		}
+	}
+	return caches
+}
+
+// SharedWith returns the CPUs sharing the data or unified cache of the
+// specified level with the specified CPU, including the CPU itself. If the CPU
+// has no such cache, the List is empty.
+func (c *Caches) SharedWith(cpu uint, level int) List {
+	for _, cache := range c.Caches {
+		if cache.Level == level && cache.Type != InstructionCache &&
+			cache.SharedCPUs.Contains(cpu) {
+			return cache.SharedCPUs
+		}
+	}
+	return List{}
+}
+
+// LastLevel returns the last-level cache of the specified CPU, that is, the
+// data or unified cache with the highest level, or false if the CPU has no
+// caches.
+func (c *Caches) LastLevel(cpu uint) (*Cache, bool) {
+	var llc *Cache
+	for idx := range c.Caches {
+		cache := &c.Caches[idx]
+		if cache.Type == InstructionCache || !cache.SharedCPUs.Contains(cpu) {
+			continue
+		}
+		if llc == nil || cache.Level > llc.Level {
+			llc = cache
+		}
+	}
+	return llc, llc != nil
+}
+
+// LLCDomains partitions the online CPUs by their last-level caches, in
+// ascending order of the lowest CPUs in each domain. On systems with multiple
+// last-level caches per package, such as AMD systems with an L3 cache per core
+// complex (CCX), each such cache forms its own domain. CPUs without cache
+// information form domains of their own.
+func (c *Caches) LLCDomains() []List {
+	var domains []List
+	assigned := List{}
+	for cpu := range c.CPUs.All() {
+		if assigned.Contains(cpu) {
+			continue
+		}
+		domain := List{{cpu, cpu}}
+		if llc, ok := c.LastLevel(cpu); ok {
+			domain = llc.SharedCPUs.Overlap(c.CPUs).Difference(assigned)
+		}
+		domains = append(domains, domain)
+		assigned = assigned.Union(domain)
+	}
+	return domains
+}
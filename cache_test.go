@@ -0,0 +1,166 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"fmt"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+// ccxFixture returns the sysfs cache files of a single-socket AMD system with
+// two core complexes (CCX) of four cores each, where each core has two
+// hardware threads. CPUs 0-7 are the first and CPUs 8-15 the second threads of
+// the cores. Each core has its own L1 and L2 caches, and each CCX has its own
+// L3 cache.
+func ccxFixture() sysfsFiles {
+	files := sysfsFiles{
+		"devices/system/cpu/online": "0-15",
+	}
+	for cpu := range 16 {
+		core := cpu % 8
+		ccx := core / 4
+		for idx, cache := range []struct {
+			level   int
+			typ     string
+			size    string
+			id      int
+			cpulist string
+		}{
+			{1, "Data", "32K", core, fmt.Sprintf("%d,%d", core, core+8)},
+			{1, "Instruction", "32K", core, fmt.Sprintf("%d,%d", core, core+8)},
+			{2, "Unified", "512K", core, fmt.Sprintf("%d,%d", core, core+8)},
+			{3, "Unified", "16384K", ccx,
+				fmt.Sprintf("%d-%d,%d-%d", ccx*4, ccx*4+3, ccx*4+8, ccx*4+11)},
+		} {
+			dir := fmt.Sprintf("devices/system/cpu/cpu%d/cache/index%d/", cpu, idx)
+			files[dir+"level"] = fmt.Sprint(cache.level)
+			files[dir+"type"] = cache.typ
+			files[dir+"size"] = cache.size
+			files[dir+"id"] = fmt.Sprint(cache.id)
+			files[dir+"shared_cpu_list"] = cache.cpulist
+		}
+	}
+	return files
+}
+
+var _ = Describe("caches", func() {
+
+	It("parses cache sizes", func() {
+		Expect(parseCacheSize("42")).To(Equal(uint64(42)))
+		Expect(parseCacheSize("48K")).To(Equal(uint64(48 << 10)))
+		Expect(parseCacheSize("32M")).To(Equal(uint64(32 << 20)))
+		Expect(parseCacheSize("1G")).To(Equal(uint64(1 << 30)))
+		Expect(parseCacheSize("K")).Error().To(HaveOccurred())
+		Expect(parseCacheSize("17179869184G")).Error().To(HaveOccurred())
+	})
+
+	It("discovers and deduplicates cache instances", func() {
+		caches := Successful(fakeSysfs(ccxFixture()).Caches())
+		Expect(caches.CPUs).To(Equal(List{{0, 15}}))
+		Expect(caches.Caches).To(HaveLen(3*8 + 2))
+		Expect(caches.Caches[0]).To(Equal(Cache{
+			Level: 1, Type: DataCache, Size: 32 << 10, ID: 0,
+			SharedCPUs: List{{0, 0}, {8, 8}},
+		}))
+		Expect(caches.Caches[8].Type).To(Equal(InstructionCache))
+		Expect(caches.Caches[len(caches.Caches)-1]).To(Equal(Cache{
+			Level: 3, Type: UnifiedCache, Size: 16 << 20, ID: 1,
+			SharedCPUs: List{{4, 7}, {12, 15}},
+		}))
+
+		Expect(caches.OfCPU(13)).To(HaveLen(4))
+		Expect(caches.OfCPU(16)).To(BeEmpty())
+		Expect(caches.SharedWith(13, 2)).To(Equal(List{{5, 5}, {13, 13}}))
+		Expect(caches.SharedWith(13, 3)).To(Equal(List{{4, 7}, {12, 15}}))
+		Expect(caches.SharedWith(13, 4)).To(BeEmpty())
+		llc, ok := caches.LastLevel(2)
+		Expect(ok).To(BeTrue())
+		Expect(llc.Level).To(Equal(3))
+		Expect(llc.ID).To(Equal(0))
+		Expect(caches.LastLevel(16)).Error().To(BeFalse())
+	})
+
+	It("partitions CCX systems into multiple last-level cache domains", func() {
+		caches := Successful(fakeSysfs(ccxFixture()).Caches())
+		Expect(caches.LLCDomains()).To(Equal([]List{
+			{{0, 3}, {8, 11}},
+			{{4, 7}, {12, 15}},
+		}))
+	})
+
+	It("partitions only online CPUs", func() {
+		caches := Successful(fakeSysfs(ccxFixture(), sysfsFiles{
+			"devices/system/cpu/online": "0-7",
+		}).Caches())
+		Expect(caches.LLCDomains()).To(Equal([]List{{{0, 3}}, {{4, 7}}}))
+	})
+
+	It("handles CPUs without cache information and optional files", func() {
+		fs := fakeSysfs(sysfsFiles{
+			"devices/system/cpu/online":                            "0-2",
+			"devices/system/cpu/cpu0/cache/index0/level":           "2",
+			"devices/system/cpu/cpu0/cache/index0/type":            "Unified",
+			"devices/system/cpu/cpu0/cache/index0/shared_cpu_list": "0-1",
+			"devices/system/cpu/cpu0/cache/uevent":                 "",
+			"devices/system/cpu/cpu1/cache/index0/level":           "2",
+			"devices/system/cpu/cpu1/cache/index0/type":            "Unified",
+			"devices/system/cpu/cpu1/cache/index0/shared_cpu_list": "0-1",
+			"devices/system/cpu/cpu1/cache/index0/size":            "1024K",
+			"devices/system/cpu/cpu2/topology/physical_package_id": "0",
+		})
+		caches := Successful(fs.Caches())
+		Expect(caches.Caches).To(Equal([]Cache{
+			{Level: 2, Type: UnifiedCache, ID: -1, SharedCPUs: List{{0, 1}}},
+		}))
+		Expect(caches.LLCDomains()).To(Equal([]List{{{0, 1}}, {{2, 2}}}))
+	})
+
+	It("reports errors", func() {
+		Expect(Sysfs(GinkgoT().TempDir()).Caches()).Error().To(MatchError(os.ErrNotExist))
+		for _, name := range []string{"level", "type", "shared_cpu_list"} {
+			fs := fakeSysfs(ccxFixture())
+			Expect(os.Remove(fs.path(sysCPUDir, "cpu3/cache/index2", name))).To(Succeed())
+			Expect(fs.Caches()).Error().To(MatchError(os.ErrNotExist), name)
+		}
+		Expect(fakeSysfs(ccxFixture(), sysfsFiles{
+			"devices/system/cpu/cpu3/cache/index2/id": "x",
+		}).Caches()).Error().To(HaveOccurred())
+		Expect(fakeSysfs(ccxFixture(), sysfsFiles{
+			"devices/system/cpu/cpu3/cache/index2/size": "xK",
+		}).Caches()).Error().To(MatchError(ContainSubstring("invalid cache size")))
+
+		fs := fakeSysfs(ccxFixture())
+		Expect(os.RemoveAll(fs.path(sysCPUDir, "cpu4/cache"))).To(Succeed())
+		Expect(os.WriteFile(fs.path(sysCPUDir, "cpu4/cache"), nil, 0o644)).To(Succeed())
+		Expect(fs.Caches()).Error().To(HaveOccurred())
+	})
+
+	It("discovers this system's caches", func() {
+		caches := Successful(DefaultSysfs.Caches())
+		domains := caches.LLCDomains()
+		Expect(domains).NotTo(BeEmpty())
+		all := List{}
+		for _, domain := range domains {
+			Expect(all.IsOverlapping(domain)).To(BeFalse())
+			all = all.Union(domain)
+		}
+		Expect(all).To(Equal(caches.CPUs))
+	})
+
+})
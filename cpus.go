@@ -0,0 +1,112 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"fmt"
+	"iter"
+)
+
+// CPUs is implemented by all representations of CPU sets in this package,
+// namely [List], [Set], and [*Mask]. It allows writing code that works on CPU
+// sets without having to care about their particular representation.
+type CPUs interface {
+	fmt.Stringer
+	// Contains reports whether cpu is in this CPU set.
+	Contains(cpu uint) bool
+	// Count returns the number of CPUs.
+	Count() int
+	// All returns an iterator over the individual CPUs, in ascending order.
+	All() iter.Seq[uint]
+	// Ranges returns an iterator over the CPU ranges, in ascending order.
+	Ranges() iter.Seq2[uint, uint]
+	// Set returns the CPUs as a Set.
+	Set() Set
+	// List returns the CPUs as a List.
+	List() List
+}
+
+var (
+	_ CPUs = List{}
+	_ CPUs = Set{}
+	_ CPUs = (*Mask)(nil)
+)
+
+// Union returns the union of the specified CPU sets as a new Set.
+func Union(cpus, another CPUs) Set {
+	return cpus.Set().Union(another.Set())
+}
+
+// Overlap returns the overlap of the specified CPU sets as a new Set.
+func Overlap(cpus, another CPUs) Set {
+	return cpus.Set().Overlap(another.Set())
+}
+
+// Difference returns a new Set with the CPUs from cpus that are not in another.
+func Difference(cpus, another CPUs) Set {
+	return cpus.Set().Difference(another.Set())
+}
+
+// SymmetricDifference returns a new Set with the CPUs that are either in cpus
+// or in another, but not in both.
+func SymmetricDifference(cpus, another CPUs) Set {
+	return cpus.Set().SymmetricDifference(another.Set())
+}
+
+// IsOverlapping returns true if the specified CPU sets overlap, otherwise
+// false.
+func IsOverlapping(cpus, another CPUs) bool {
+	return cpus.Set().IsOverlapping(another.Set())
+}
+
+// IsEqual returns true if the specified CPU sets contain the same CPUs, otherwise
+// false.
+func IsEqual(cpus, another CPUs) bool {
+	return cpus.Set().Equal(another.Set())
+}
+
+// Pin pins the process/task identified by tid to the specified CPUs. If it
+// fails, it returns an error instead. See also [SetAffinity].
+func Pin(tid int, cpus CPUs) error {
+	return SetAffinity(tid, cpus.Set())
+}
+
+// Take allocates the n lowest CPUs from the specified CPU set, returning the
+// allocated CPUs together with the remaining CPUs, and true. If there are less
+// than n CPUs available, Take returns false instead.
+//
+// Take is useful to allocate CPUs from the CPU affinities of a task/process,
+// see also [List.Remove] for allocating single CPUs.
+func Take(cpus CPUs, n int) (taken, remaining List, ok bool) {
+	if n < 0 || cpus.Count() < n {
+		return nil, nil, false
+	}
+	taken, remaining = List{}, List{}
+	for from, to := range cpus.Ranges() {
+		if n == 0 {
+			remaining = append(remaining, [2]uint{from, to})
+			continue
+		}
+		if size := to - from + 1; size == 0 || uint(n) < size {
+			taken = append(taken, [2]uint{from, from + uint(n) - 1})
+			remaining = append(remaining, [2]uint{from + uint(n), to})
+			n = 0
+			continue
+		}
+		taken = append(taken, [2]uint{from, to})
+		n -= int(to-from) + 1
+	}
+	return taken, remaining, true
+}
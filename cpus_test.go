@@ -0,0 +1,167 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"runtime"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+// representations returns the specified CPU list text in all representations
+// implementing the CPUs interface.
+func representations(text string) []CPUs {
+	GinkgoHelper()
+	l := Successful(NewList([]byte(text)))
+	return []CPUs{l, l.Set(), mustMask(text)}
+}
+
+// positional is implemented by all representations in addition to the CPUs
+// interface, answering positional queries.
+type positional interface {
+	CPUs
+	Min() (cpu uint, ok bool)
+	Max() (cpu uint, ok bool)
+	Nth(n int) (cpu uint, ok bool)
+	IndexOf(cpu uint) (n int, ok bool)
+}
+
+var _ = Describe("CPUs", func() {
+
+	It("queries all representations the same", func() {
+		for _, cpus := range representations("1-3,5,63-64,200") {
+			Expect(cpus.String()).To(Equal("1-3,5,63-64,200"))
+			Expect(cpus.Count()).To(Equal(7))
+			Expect(cpus.Contains(64)).To(BeTrue())
+			Expect(cpus.Contains(4)).To(BeFalse())
+			Expect(slices.Collect(cpus.All())).To(Equal([]uint{1, 2, 3, 5, 63, 64, 200}))
+			var ranges List
+			for from, to := range cpus.Ranges() {
+				ranges = append(ranges, [2]uint{from, to})
+			}
+			Expect(ranges).To(Equal(List{{1, 3}, {5, 5}, {63, 64}, {200, 200}}))
+			Expect(cpus.List()).To(Equal(ranges))
+			Expect(cpus.Set().String()).To(Equal("1-3,5,63-64,200"))
+		}
+	})
+
+	DescribeTable("counting and finding lowest and highest CPUs",
+		func(text string, count int, lowest, highest int) {
+			for _, cpus := range representations(text) {
+				cpus := cpus.(positional)
+				Expect(cpus.Count()).To(Equal(count))
+				if count == 0 {
+					_, ok := cpus.Min()
+					Expect(ok).To(BeFalse())
+					_, ok = cpus.Max()
+					Expect(ok).To(BeFalse())
+					continue
+				}
+				Expect(Found(cpus.Min())).To(Equal(uint(lowest)))
+				Expect(Found(cpus.Max())).To(Equal(uint(highest)))
+			}
+		},
+		Entry(nil, "", 0, 0, 0),
+		Entry(nil, "42", 1, 42, 42),
+		Entry(nil, "1-3,5", 4, 1, 5),
+		Entry(nil, "0-63", 64, 0, 63),
+		Entry(nil, "63-64,200-300", 103, 63, 300),
+	)
+
+	It("picks the nth CPU and finds the index of CPUs", func() {
+		expected := []uint{1, 2, 3, 5, 63, 64}
+		for cpu := uint(200); cpu <= 300; cpu++ {
+			expected = append(expected, cpu)
+		}
+		for _, cpus := range representations("1-3,5,63-64,200-300") {
+			cpus := cpus.(positional)
+			for n, cpu := range expected {
+				Expect(Found(cpus.Nth(n))).To(Equal(cpu), "Nth(%d)", n)
+				Expect(FoundIndex(cpus.IndexOf(cpu))).To(Equal(n), "IndexOf(%d)", cpu)
+				Expect(cpus.Contains(cpu)).To(BeTrue(), "Contains(%d)", cpu)
+			}
+			_, ok := cpus.Nth(-1)
+			Expect(ok).To(BeFalse())
+			_, ok = cpus.Nth(len(expected))
+			Expect(ok).To(BeFalse())
+			for _, cpu := range []uint{0, 4, 6, 62, 65, 199, 301, 666} {
+				Expect(cpus.IndexOf(cpu)).Error().To(BeFalse(), "IndexOf(%d)", cpu)
+				Expect(cpus.Contains(cpu)).To(BeFalse(), "Contains(%d)", cpu)
+			}
+		}
+	})
+
+	DescribeTable("set algebra across representations",
+		func(text1, text2 string, overlap, union, diff, symdiff string) {
+			for _, cpus1 := range representations(text1) {
+				for _, cpus2 := range representations(text2) {
+					Expect(IsOverlapping(cpus1, cpus2)).To(Equal(overlap != ""))
+					Expect(Overlap(cpus1, cpus2).String()).To(Equal(overlap))
+					Expect(Union(cpus1, cpus2).String()).To(Equal(union))
+					Expect(Difference(cpus1, cpus2).String()).To(Equal(diff))
+					Expect(SymmetricDifference(cpus1, cpus2).String()).To(Equal(symdiff))
+					Expect(IsEqual(cpus1, cpus2)).To(Equal(text1 == text2))
+				}
+			}
+		},
+		Entry(nil, "", "", "", "", "", ""),
+		Entry(nil, "1-5", "3-9", "3-5", "1-9", "1-2", "1-2,6-9"),
+		Entry(nil, "1-3", "64-66", "", "1-3,64-66", "1-3", "1-3,64-66"),
+		Entry(nil, "1-3,64", "1-3,64", "1-3,64", "1-3,64", "", ""),
+	)
+
+	DescribeTable("taking CPUs",
+		func(text string, n int, taken, remaining string) {
+			for _, cpus := range representations(text) {
+				t, r, ok := Take(cpus, n)
+				Expect(ok).To(BeTrue())
+				Expect(t.String()).To(Equal(taken))
+				Expect(r.String()).To(Equal(remaining))
+			}
+		},
+		Entry(nil, "", 0, "", ""),
+		Entry(nil, "1-3,5", 0, "", "1-3,5"),
+		Entry(nil, "1-3,5", 1, "1", "2-3,5"),
+		Entry(nil, "1-3,5", 3, "1-3", "5"),
+		Entry(nil, "1-3,5", 4, "1-3,5", ""),
+		Entry(nil, "1-3,5-9,11", 5, "1-3,5-6", "7-9,11"),
+	)
+
+	It("doesn't take more CPUs than available", func() {
+		for _, cpus := range representations("1-3") {
+			_, _, ok := Take(cpus, 4)
+			Expect(ok).To(BeFalse())
+			_, _, ok = Take(cpus, -1)
+			Expect(ok).To(BeFalse())
+		}
+	})
+
+	It("pins to CPUs in any representation", func() {
+		runtime.LockOSThread() // don't unlock, throw away the tainted task
+
+		affs := Successful(Affinity(0))
+		cpu := Found(affs.Min())
+		for _, cpus := range representations(List{{cpu, cpu}}.String()) {
+			Expect(Pin(0, cpus)).To(Succeed())
+			Expect(Successful(Affinity(0)).List()).To(Equal(List{{cpu, cpu}}))
+		}
+		Expect(Pin(0, affs)).To(Succeed())
+	})
+
+})
@@ -15,5 +15,18 @@ and procfs pseudo files.
 
 [List.Set] converts a List into its corresponding Set. In the opposite
 direction, [Set.List] converts a Set into its equivalent List.
+
+Additionally, [Mask] is a fixed-size and comparable variant of Set, avoiding
+heap allocations and suitable for use as map keys.
+
+All three representations implement the [CPUs] interface, so package-level
+functions such as [Union], [Difference], [Pin], and [Take] accept any of them.
+
+[Sysfs] discovers the CPUs of a system from sysfs, returning their states
+([Sysfs.SystemCPUs]), topology ([Sysfs.Topology]), NUMA nodes ([Sysfs.NUMA]),
+caches ([Sysfs.Caches]), and core classes on hybrid systems
+([Sysfs.CoreClasses]), all in terms of Lists. Use [DefaultSysfs] for the
+system's own sysfs, or another Sysfs root when the sysfs of interest is mounted
+elsewhere.
 */
 package cpus
@@ -0,0 +1,146 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	_ encoding.TextMarshaler   = List{}
+	_ encoding.TextUnmarshaler = (*List)(nil)
+	_ json.Marshaler           = List{}
+	_ json.Unmarshaler         = (*List)(nil)
+
+	_ encoding.TextMarshaler   = Set{}
+	_ encoding.TextUnmarshaler = (*Set)(nil)
+	_ json.Marshaler           = Set{}
+	_ json.Unmarshaler         = (*Set)(nil)
+)
+
+// MarshalText returns the CPU list in textual format, as returned by
+// [List.String]. This allows encoding Lists in text-based formats, such as
+// JSON, YAML, and TOML.
+func (l List) MarshalText() ([]byte, error) {
+	return l.AppendText(nil)
+}
+
+// UnmarshalText sets this List to the CPUs from the specified text in list
+// format, as described in [NewList]. If the text is malformed, then a
+// [*ParseError] is returned instead and this List is left unchanged.
+func (l *List) UnmarshalText(text []byte) error {
+	list, err := NewList(text)
+	if err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// MarshalJSON returns the CPU list as a JSON string in textual list format,
+// such as "2-5,8". As CPU list text only consists of digits, “,” and “-”, it
+// never needs any escaping.
+func (l List) MarshalJSON() ([]byte, error) {
+	return append(l.appendText([]byte{'"'}), '"'), nil
+}
+
+// UnmarshalJSON sets this List to the CPUs from the specified JSON data, which
+// is either a string in textual list format, such as "2-5,8", or an array of
+// CPU numbers, such as [2,3,4,5,8]. A JSON null leaves this List unchanged.
+func (l *List) UnmarshalJSON(data []byte) error {
+	list, err := unmarshalJSONList(data)
+	if err != nil || list == nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// MarshalText returns the CPUs in this Set in textual list format, as returned
+// by [Set.String].
+func (s Set) MarshalText() ([]byte, error) {
+	return s.AppendText(nil)
+}
+
+// UnmarshalText sets this Set to the CPUs from the specified text in list
+// format, as described in [NewList]. If the text is malformed, then a
+// [*ParseError] is returned instead and this Set is left unchanged.
+func (s *Set) UnmarshalText(text []byte) error {
+	set, err := NewSet(text)
+	if err != nil {
+		return err
+	}
+	*s = set
+	return nil
+}
+
+// MarshalJSON returns the CPUs in this Set as a JSON string in textual list
+// format, such as "2-5,8".
+func (s Set) MarshalJSON() ([]byte, error) {
+	return append(s.appendText([]byte{'"'}), '"'), nil
+}
+
+// UnmarshalJSON sets this Set to the CPUs from the specified JSON data, which
+// is either a string in textual list format, such as "2-5,8", or an array of
+// CPU numbers, such as [2,3,4,5,8]. A JSON null leaves this Set unchanged.
+// Arrays with CPU numbers above 16777215 are rejected with [ErrBeyondCapacity].
+func (s *Set) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		return s.UnmarshalText([]byte(text))
+	}
+	list, err := unmarshalJSONList(data)
+	if err != nil || list == nil {
+		return err
+	}
+	if cpu, ok := list.Max(); ok && cpu > maxSetCPU {
+		return fmt.Errorf("%w: Set holds only %d CPUs, found CPU %d",
+			ErrBeyondCapacity, maxSetCPU+1, cpu)
+	}
+	*s = list.Set()
+	return nil
+}
+
+// unmarshalJSONList returns the List for the specified JSON data, which is
+// either a string in textual list format or an array of CPU numbers. For a JSON
+// null it returns a nil List.
+func unmarshalJSONList(data []byte) (List, error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil, nil
+	case len(data) > 0 && data[0] == '"':
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return nil, err
+		}
+		return NewList([]byte(text))
+	case len(data) > 0 && data[0] == '[':
+		var cpus []int
+		if err := json.Unmarshal(data, &cpus); err != nil {
+			return nil, err
+		}
+		return FromSlice(cpus)
+	}
+	return nil, errors.New("expected JSON string or array of CPU numbers")
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+type config struct {
+	List List `json:"list"`
+	Set  Set  `json:"set"`
+}
+
+var _ = Describe("text and JSON encoding", func() {
+
+	DescribeTable("round-tripping text",
+		func(text string) {
+			l := Successful(NewList([]byte(text)))
+			Expect(l.MarshalText()).To(Equal([]byte(text)))
+			Expect(l.Set().MarshalText()).To(Equal([]byte(text)))
+
+			var l2 List
+			Expect(l2.UnmarshalText([]byte(text))).To(Succeed())
+			Expect(l2).To(Equal(l))
+			var s Set
+			Expect(s.UnmarshalText([]byte(text))).To(Succeed())
+			Expect(s.List()).To(Equal(l))
+		},
+		Entry(nil, ""),
+		Entry(nil, "42"),
+		Entry(nil, "2-5,8,64-127"),
+	)
+
+	It("rejects malformed text, leaving the destination unchanged", func() {
+		l := List{{1, 1}}
+		Expect(l.UnmarshalText([]byte("1-"))).To(MatchError(ErrExpectedNumber))
+		Expect(l).To(Equal(List{{1, 1}}))
+		s := Set{0x2}
+		Expect(s.UnmarshalText([]byte("1,,2"))).To(MatchError(ErrExpectedNumber))
+		Expect(s).To(Equal(Set{0x2}))
+	})
+
+	It("marshals to JSON strings", func() {
+		Expect(json.Marshal(config{
+			List: List{{2, 5}, {8, 8}},
+			Set:  Set{0x3},
+		})).To(MatchJSON(`{"list":"2-5,8","set":"0-1"}`))
+		Expect(json.Marshal(config{})).To(MatchJSON(`{"list":"","set":""}`))
+	})
+
+	DescribeTable("unmarshalling from JSON",
+		func(data string, expected string) {
+			var c config
+			Expect(json.Unmarshal([]byte(data), &c)).To(Succeed())
+			Expect(c.List.String()).To(Equal(expected))
+			Expect(c.Set.String()).To(Equal(expected))
+		},
+		Entry(nil, `{"list":"2-5,8","set":"2-5,8"}`, "2-5,8"),
+		Entry(nil, `{"list":[8,2,3,4,5],"set":[5,4,3,2,8]}`, "2-5,8"),
+		Entry(nil, `{"list":[],"set":[]}`, ""),
+		Entry(nil, `{"list":"","set":""}`, ""),
+		Entry(nil, `{"list":null,"set":null}`, ""),
+	)
+
+	It("leaves the destination unchanged on JSON null", func() {
+		c := config{List: List{{1, 1}}, Set: Set{0x2}}
+		Expect(json.Unmarshal([]byte(`{"list":null,"set":null}`), &c)).To(Succeed())
+		Expect(c.List).To(Equal(List{{1, 1}}))
+		Expect(c.Set).To(Equal(Set{0x2}))
+	})
+
+	DescribeTable("rejecting invalid JSON",
+		func(data string, kind error) {
+			var c config
+			err := json.Unmarshal([]byte(data), &c)
+			Expect(err).To(HaveOccurred())
+			if kind != nil {
+				Expect(err).To(MatchError(kind))
+			}
+		},
+		Entry(nil, `{"list":"1-"}`, ErrExpectedNumber),
+		Entry(nil, `{"set":"1-"}`, ErrExpectedNumber),
+		Entry(nil, `{"list":[1,-1]}`, ErrNegativeCPU),
+		Entry(nil, `{"set":[-1]}`, ErrNegativeCPU),
+		Entry(nil, `{"set":[1,16777216]}`, ErrBeyondCapacity),
+		Entry(nil, `{"list":42}`, nil),
+		Entry(nil, `{"set":{}}`, nil),
+		Entry(nil, `{"list":["1"]}`, nil),
+	)
+
+})
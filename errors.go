@@ -0,0 +1,97 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of errors when parsing textual CPU lists, as reported by
+// [ParseError.Err]; use [errors.Is] to check for a specific kind.
+var (
+	ErrExpectedNumber    = errors.New("expected unsigned integer number")
+	ErrInvertedRange     = errors.New("invalid inverted range")
+	ErrExpectedSeparator = errors.New("expected separator")
+	ErrCPUOutOfRange     = errors.New("CPU number out of range")
+	ErrInvalidGroup      = errors.New("invalid used/group sizes")
+	ErrMaxCPURequired    = errors.New("“all” and “N” require a maximum CPU")
+)
+
+// ErrBeyondCapacity is reported when CPUs don't fit into fixed-size types,
+// such as [Mask].
+var ErrBeyondCapacity = errors.New("CPU beyond capacity")
+
+// ErrNegativeCPU is reported when converting from representations allowing
+// negative CPU numbers, such as in [FromSlice].
+var ErrNegativeCPU = errors.New("invalid negative CPU number")
+
+// ErrInvalidEncoding is reported when decoding invalid binary CPU set data, such
+// as in [List.UnmarshalBinary] and [Set.UnmarshalBinary].
+var ErrInvalidEncoding = errors.New("invalid binary CPU set encoding")
+
+// ParseError describes a problem parsing a textual CPU list, such as by
+// [NewList] and [ParseList], including the position where the problem was
+// found.
+type ParseError struct {
+	Input  string // the CPU list text being parsed.
+	Offset int    // byte offset into Input where the problem was found.
+	Token  string // offending token at Offset; empty at the end of Input.
+	Err    error  // kind of problem, such as ErrExpectedNumber.
+}
+
+// Error returns a textual description of the parse error, including its byte
+// offset and offending token.
+func (e *ParseError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("%s at offset %d, found end of input", e.Err, e.Offset)
+	}
+	return fmt.Sprintf("%s at offset %d, found %q", e.Err, e.Offset, e.Token)
+}
+
+// Unwrap returns the kind of parse error, such as [ErrExpectedNumber].
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// newParseError returns a new ParseError of the specified kind at the specified
+// byte offset into text, determining the offending token at this offset.
+//
+// The offending token is either a run of digits, a run of letters, or
+// otherwise a single character.
+func newParseError(text []byte, offset int, kind error) *ParseError {
+	end := offset
+	switch {
+	case end >= len(text):
+	case isDigit(text[end]):
+		for end < len(text) && isDigit(text[end]) {
+			end++
+		}
+	case isLetter(text[end]):
+		for end < len(text) && isLetter(text[end]) {
+			end++
+		}
+	default:
+		end++
+	}
+	return &ParseError{
+		Input:  string(text),
+		Offset: offset,
+		Token:  string(text[offset:end]),
+		Err:    kind,
+	}
+}
+
+func isDigit(ch byte) bool { return ch >= '0' && ch <= '9' }
+
+func isLetter(ch byte) bool { return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') }
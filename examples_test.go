@@ -14,6 +14,8 @@
 
 package cpus
 
+import "fmt"
+
 // Pick two CPUs from the CPUs available to this process/task.
 func ExampleList_Remove() {
 	availset, err := Affinity(0)
@@ -25,3 +27,19 @@ func ExampleList_Remove() {
 	println(acpu, anothercpu)
 	// Output:
 }
+
+// Walk all CPUs in a list without modifying it.
+func ExampleList_All() {
+	cpulist, err := NewList([]byte("1-3,42"))
+	if err != nil {
+		panic(err)
+	}
+	for cpu := range cpulist.All() {
+		fmt.Println(cpu)
+	}
+	// Output:
+	// 1
+	// 2
+	// 3
+	// 42
+}
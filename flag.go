@@ -0,0 +1,100 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import "flag"
+
+// ListValue adapts a [List] for use as a command-line flag value in textual list
+// format, as described in [NewList]. ListValue implements [flag.Value] and
+// [flag.Getter], as well as the Type method of the pflag Value interface, so it
+// can be used with both the standard library's flag package and
+// github.com/spf13/pflag. For instance:
+//
+//	var l cpus.List
+//	fs.Var((*cpus.ListValue)(&l), "cpus", "CPUs to run on")
+//
+// See also [ListVar]. As List already has a Set method for converting Lists
+// into Sets, List cannot be a flag value itself.
+type ListValue List
+
+var _ flag.Getter = (*ListValue)(nil)
+
+// String returns the CPU list in textual format. As required by [flag.Value],
+// String also works on a nil receiver, returning the empty string.
+func (v *ListValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return List(*v).String()
+}
+
+// Set sets the flag value to the CPUs from the specified text in list format.
+// If the text is malformed then a [*ParseError] is returned instead, telling the
+// offending position.
+func (v *ListValue) Set(text string) error {
+	return (*List)(v).UnmarshalText([]byte(text))
+}
+
+// Type returns the name of the flag value type, as used by pflag in usage
+// messages.
+func (v *ListValue) Type() string { return "cpulist" }
+
+// Get returns the List.
+func (v *ListValue) Get() any { return List(*v) }
+
+// SetValue adapts a [Set] for use as a command-line flag value in textual list
+// format, in the same way as [ListValue] does for Lists.
+type SetValue Set
+
+var _ flag.Getter = (*SetValue)(nil)
+
+// String returns the CPUs in textual list format. String also works on a nil
+// receiver, returning the empty string.
+func (v *SetValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return Set(*v).String()
+}
+
+// Set sets the flag value to the CPUs from the specified text in list format.
+// If the text is malformed then a [*ParseError] is returned instead, telling the
+// offending position.
+func (v *SetValue) Set(text string) error {
+	return (*Set)(v).UnmarshalText([]byte(text))
+}
+
+// Type returns the name of the flag value type, as used by pflag in usage
+// messages.
+func (v *SetValue) Type() string { return "cpulist" }
+
+// Get returns the Set.
+func (v *SetValue) Get() any { return Set(*v) }
+
+// ListVar defines a List flag with the specified name, default value, and usage
+// string in the specified flag set. The argument l points to a List variable in
+// which to store the value of the flag.
+func ListVar(fs *flag.FlagSet, l *List, name string, value List, usage string) {
+	*l = value
+	fs.Var((*ListValue)(l), name, usage)
+}
+
+// SetVar defines a Set flag with the specified name, default value, and usage
+// string in the specified flag set. The argument s points to a Set variable in
+// which to store the value of the flag.
+func SetVar(fs *flag.FlagSet, s *Set, name string, value Set, usage string) {
+	*s = value
+	fs.Var((*SetValue)(s), name, usage)
+}
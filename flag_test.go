@@ -0,0 +1,104 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"flag"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+// pflagValue mirrors github.com/spf13/pflag.Value.
+type pflagValue interface {
+	String() string
+	Set(string) error
+	Type() string
+}
+
+var (
+	_ pflagValue = (*ListValue)(nil)
+	_ pflagValue = (*SetValue)(nil)
+)
+
+var _ = Describe("command-line flags", func() {
+
+	var fs *flag.FlagSet
+
+	BeforeEach(func() {
+		fs = flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+	})
+
+	It("parses CPU lists and sets", func() {
+		var l List
+		var s Set
+		ListVar(fs, &l, "cpus", List{{0, 1}}, "CPUs to use")
+		SetVar(fs, &s, "others", nil, "other CPUs to use")
+		Expect(l).To(Equal(List{{0, 1}}))
+		Expect(fs.Parse([]string{"-cpus", "2-5,8", "-others=1,64"})).To(Succeed())
+		Expect(l).To(Equal(List{{2, 5}, {8, 8}}))
+		Expect(s.String()).To(Equal("1,64"))
+
+		Expect(fs.Lookup("cpus").Value.(flag.Getter).Get()).To(Equal(l))
+		Expect(fs.Lookup("others").Value.(flag.Getter).Get()).To(Equal(s))
+	})
+
+	It("keeps defaults", func() {
+		var l List
+		ListVar(fs, &l, "cpus", List{{0, 1}}, "CPUs to use")
+		Expect(fs.Parse(nil)).To(Succeed())
+		Expect(l).To(Equal(List{{0, 1}}))
+		Expect(fs.Lookup("cpus").DefValue).To(Equal("0-1"))
+	})
+
+	It("reports the offending position", func() {
+		var l List
+		ListVar(fs, &l, "cpus", nil, "CPUs to use")
+		err := fs.Parse([]string{"-cpus", "1,2,x"})
+		Expect(err).To(MatchError(ContainSubstring(
+			`expected unsigned integer number at offset 4, found "x"`)))
+
+		var s Set
+		SetVar(fs, &s, "others", nil, "other CPUs to use")
+		err = fs.Parse([]string{"-others", "3-1"})
+		Expect(err).To(MatchError(ContainSubstring(
+			`invalid inverted range at offset 2, found "1"`)))
+	})
+
+	It("renders nil values as empty strings", func() {
+		Expect((*ListValue)(nil).String()).To(BeEmpty())
+		Expect((*SetValue)(nil).String()).To(BeEmpty())
+	})
+
+	It("tells its type and prints usage", func() {
+		var l List
+		var s Set
+		Expect((*ListValue)(&l).Type()).To(Equal("cpulist"))
+		Expect((*SetValue)(&s).Type()).To(Equal("cpulist"))
+
+		var usage strings.Builder
+		fs.SetOutput(&usage)
+		ListVar(fs, &l, "cpus", List{{0, 3}}, "CPUs to use")
+		SetVar(fs, &s, "others", nil, "other CPUs to use")
+		fs.PrintDefaults()
+		Expect(usage.String()).To(SatisfyAll(
+			ContainSubstring("CPUs to use (default 0-3)"),
+			Not(ContainSubstring("other CPUs to use (default"))))
+	})
+
+})
@@ -0,0 +1,156 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+var (
+	_ fmt.Formatter = List{}
+	_ fmt.Formatter = Set{}
+)
+
+// Format formats this List according to the specified verb:
+//   - %v and %s: textual list format, such as “1-3,5”. With the “+” flag, the
+//     number of CPUs is added, such as “1-3,5 (4 CPUs)”. With the “#” flag,
+//     %v formats this List in Go syntax.
+//   - %x and %X: the kernel's hex mask format, such as “2e”, in lower and
+//     upper case, respectively; see also [Set.MaskString].
+//   - %b: bit string with CPU 0 as the rightmost bit, such as “101110”.
+//   - %d: number of CPUs.
+//
+// The width and the “-” and “0” flags are honored, where zero padding only
+// applies to %x, %X, %b, and %d. As zero padding must keep hex masks valid, %x
+// and %X are zero padded to whole 32 bit chunks, such as “000000ff,ffffffff”,
+// which may exceed the width.
+func (l List) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		b := []byte("cpus.List{")
+		for idx, r := range l {
+			if idx > 0 {
+				b = append(b, ", "...)
+			}
+			b = fmt.Appendf(b, "{%d, %d}", r[0], r[1])
+		}
+		_, _ = f.Write(append(b, '}'))
+		return
+	}
+	format(f, verb, l, "List")
+}
+
+// Format formats this Set according to the specified verb, in the same way as
+// [List.Format] does. With the “#” flag, %v formats this Set in Go syntax.
+func (s Set) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		b := []byte("cpus.Set{")
+		for idx, word := range s {
+			if idx > 0 {
+				b = append(b, ", "...)
+			}
+			b = fmt.Appendf(b, "%#x", word)
+		}
+		_, _ = f.Write(append(b, '}'))
+		return
+	}
+	format(f, verb, s, "Set")
+}
+
+// format formats the specified CPUs according to the specified verb, flags and
+// width.
+func format(f fmt.State, verb rune, cpus CPUs, typename string) {
+	var b []byte
+	numeric := true
+	switch verb {
+	case 'v', 's':
+		numeric = false
+		b = append(b, cpus.String()...)
+		if f.Flag('+') {
+			count := cpus.Count()
+			b = append(b, " ("...)
+			b = strconv.AppendInt(b, int64(count), 10)
+			if count == 1 {
+				b = append(b, " CPU)"...)
+			} else {
+				b = append(b, " CPUs)"...)
+			}
+		}
+	case 'd':
+		b = strconv.AppendInt(b, int64(cpus.Count()), 10)
+	case 'x':
+		b = appendMask(b, cpus.Set(), f)
+	case 'X':
+		b = bytes.ToUpper(appendMask(b, cpus.Set(), f))
+	case 'b':
+		b = appendBits(b, cpus.Set())
+	default:
+		fmt.Fprintf(f, "%%!%c(cpus.%s=%s)", verb, typename, cpus.String())
+		return
+	}
+	width, ok := f.Width()
+	if !ok || width <= len(b) {
+		_, _ = f.Write(b)
+		return
+	}
+	padding := bytes.Repeat([]byte{' '}, width-len(b))
+	switch {
+	case f.Flag('-'):
+		b = append(b, padding...)
+	case f.Flag('0') && numeric && verb != 'x' && verb != 'X':
+		b = append(bytes.Repeat([]byte{'0'}, len(padding)), b...)
+	default:
+		b = append(padding, b...)
+	}
+	_, _ = f.Write(b)
+}
+
+// appendMask appends the CPUs in the specified Set in hex mask format to dst.
+// When zero padding to a width, appendMask renders as many whole 32 bit chunks
+// as necessary to reach the width, as zeros in front of the chunk separators
+// would otherwise result in an invalid mask.
+func appendMask(dst []byte, s Set, f fmt.State) []byte {
+	width, ok := f.Width()
+	if !ok || !f.Flag('0') || f.Flag('-') {
+		return s.AppendMask(dst, 0)
+	}
+	chunks := uint(1)
+	if cpu, ok := s.Max(); ok {
+		chunks = cpu/32 + 1
+	}
+	// Each chunk takes 8 hex digits plus a separator, except for the last.
+	chunks = max(chunks, (uint(width)+9)/9)
+	return s.AppendMask(dst, chunks*32)
+}
+
+// appendBits appends the CPUs in the specified Set as a bit string to dst,
+// starting with the highest CPU and ending with CPU 0. The empty Set is
+// represented as “0”.
+func appendBits(dst []byte, s Set) []byte {
+	s = s.Compact()
+	if len(s) == 0 {
+		return append(dst, '0')
+	}
+	top := len(s) - 1
+	dst = strconv.AppendUint(dst, s[top], 2)
+	for idx := top - 1; idx >= 0; idx-- {
+		word := s[idx]
+		for bit := int(bitsperword) - 1; bit >= 0; bit-- {
+			dst = append(dst, '0'+byte(word>>bit&1))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("formatting", func() {
+
+	DescribeTable("formatting lists and sets",
+		func(format string, text string, expected string) {
+			l := Successful(NewList([]byte(text)))
+			Expect(fmt.Sprintf(format, l)).To(Equal(expected))
+			Expect(fmt.Sprintf(format, l.Set())).To(Equal(expected))
+		},
+		Entry(nil, "%v", "1-3,5", "1-3,5"),
+		Entry(nil, "%s", "1-3,5", "1-3,5"),
+		Entry(nil, "%v", "", ""),
+		Entry(nil, "%+v", "1-3,5", "1-3,5 (4 CPUs)"),
+		Entry(nil, "%+s", "42", "42 (1 CPU)"),
+		Entry(nil, "%+v", "", " (0 CPUs)"),
+		Entry(nil, "%8v", "1-3,5", "   1-3,5"),
+		Entry(nil, "%-8v|", "1-3,5", "1-3,5   |"),
+		Entry(nil, "%08v", "1-3,5", "   1-3,5"),
+		Entry(nil, "%d", "1-3,5", "4"),
+		Entry(nil, "%04d", "1-3,5", "0004"),
+		Entry(nil, "%x", "1-3,5", "2e"),
+		Entry(nil, "%x", "", "0"),
+		Entry(nil, "%x", "0-39", "ff,ffffffff"),
+		Entry(nil, "%X", "0-39", "FF,FFFFFFFF"),
+		Entry(nil, "%14x", "0-39", "   ff,ffffffff"),
+		Entry(nil, "%020x", "0-39", "00000000,000000ff,ffffffff"),
+		Entry(nil, "%017x", "0-39", "000000ff,ffffffff"),
+		Entry(nil, "%08X", "1-3,5", "0000002E"),
+		Entry(nil, "%04x", "1-3,5", "0000002e"),
+		Entry(nil, "%010x", "", "00000000,00000000"),
+		Entry(nil, "%-010x|", "1-3,5", "2e        |"),
+		Entry(nil, "%b", "1-3,5", "101110"),
+		Entry(nil, "%b", "", "0"),
+		Entry(nil, "%b", "64", "1"+strings.Repeat("0", 64)),
+		Entry(nil, "%b", "0,63", "1"+strings.Repeat("0", 62)+"1"),
+		Entry(nil, "%08b", "1-3,5", "00101110"),
+		Entry(nil, "%8b", "1-3,5", "  101110"),
+		Entry(nil, "%-8b|", "1-3,5", "101110  |"),
+	)
+
+	DescribeTable("round-tripping zero-padded hex masks",
+		func(format string, text string) {
+			l := Successful(NewList([]byte(text)))
+			for _, cpus := range []any{l, l.Set()} {
+				mask := fmt.Sprintf(format, cpus)
+				s := Successful(NewSetFromMask([]byte(mask)))
+				Expect(ParseList([]byte(s.String()))).To(Equal(l), mask)
+			}
+		},
+		Entry(nil, "%020x", "0-39"),
+		Entry(nil, "%030X", "1,33,64-65"),
+		Entry(nil, "%09x", ""),
+	)
+
+	It("reports invalid verbs", func() {
+		Expect(fmt.Sprintf("%z", List{{1, 3}})).To(Equal("%!z(cpus.List=1-3)"))
+		Expect(fmt.Sprintf("%z", Set{0xe})).To(Equal("%!z(cpus.Set=1-3)"))
+	})
+
+	It("formats in Go syntax", func() {
+		Expect(fmt.Sprintf("%#v", List{{1, 3}, {5, 5}})).To(Equal("cpus.List{{1, 3}, {5, 5}}"))
+		Expect(fmt.Sprintf("%#v", List{})).To(Equal("cpus.List{}"))
+		Expect(fmt.Sprintf("%#v", Set{0x2e, 0x1})).To(Equal("cpus.Set{0x2e, 0x1}"))
+	})
+
+})
@@ -6,7 +6,6 @@ toolchain go1.24.2
 
 require (
 	github.com/onsi/ginkgo/v2 v2.23.4
-	github.com/thediveo/faf v0.2.1
 	golang.org/x/sys v0.32.0
 )
 
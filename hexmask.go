@@ -0,0 +1,170 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"errors"
+)
+
+// maskChunkBits is the number of bits in each comma-separated chunk of the
+// kernel's hex mask format.
+const maskChunkBits = 32
+
+// NewSetFromMask returns a new CPU Set for the given text in the kernel's hex
+// mask format, as used in “/proc/irq/N/smp_affinity”, the “Cpus_allowed” field
+// in “/proc/PID/status”, et cetera. If the text is malformed then an error is
+// returned instead.
+//
+// The hex mask format consists of one or more comma-separated chunks of up to
+// eight hex digits each, representing 32 CPUs per chunk. The chunks are in
+// big-endian order, so the last chunk represents CPUs 0–31. Chunks may contain
+// leading zeros. A single trailing “\n” is accepted, as returned when reading
+// from procfs and sysfs files. The empty text returns an empty Set.
+//
+// Valid hex masks:
+//   - “” (empty CPU set)
+//   - “f” (CPUs 0–3)
+//   - “ff,ffffffff” (CPUs 0–39)
+//   - “00000001,00000000\n” (CPU 32)
+//
+// Invalid hex masks (non-exhaustive examples):
+//   - “,ff” (empty chunk)
+//   - “123456789” (chunk too long)
+//   - “0x1f” (garbage)
+func NewSetFromMask(text []byte) (Set, error) {
+	if len(text) > 0 && text[len(text)-1] == '\n' {
+		text = text[:len(text)-1]
+	}
+	if len(text) == 0 {
+		return Set{}, nil
+	}
+	// Determine the number of chunks first, so we can allocate the Set only
+	// once and then fill it in from its most significant chunk downwards.
+	chunks := 1
+	for _, ch := range text {
+		if ch == ',' {
+			chunks++
+		}
+	}
+	set := make(Set, (chunks*maskChunkBits+int(bitsperword)-1)/int(bitsperword))
+	chunkIdx := chunks - 1
+	digits := 0
+	var chunk uint64
+	for idx := 0; ; idx++ {
+		if idx == len(text) || text[idx] == ',' {
+			if digits == 0 {
+				return nil, errors.New("expected hex digit")
+			}
+			set[chunkIdx/2] |= chunk << ((chunkIdx % 2) * maskChunkBits)
+			if idx == len(text) {
+				return set, nil
+			}
+			chunkIdx--
+			digits = 0
+			chunk = 0
+			continue
+		}
+		digit, ok := hexDigit(text[idx])
+		if !ok {
+			return nil, errors.New("expected hex digit or ','")
+		}
+		if digits == maskChunkBits/4 {
+			return nil, errors.New("too many hex digits in chunk")
+		}
+		chunk = chunk<<4 | uint64(digit)
+		digits++
+	}
+}
+
+// hexDigit returns the value of the specified hex digit and true, otherwise
+// false.
+func hexDigit(ch byte) (byte, bool) {
+	switch {
+	case ch >= '0' && ch <= '9':
+		return ch - '0', true
+	case ch >= 'a' && ch <= 'f':
+		return ch - 'a' + 10, true
+	case ch >= 'A' && ch <= 'F':
+		return ch - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// MaskString returns the CPUs in this set in the kernel's hex mask format, such
+// as “ff,ffffffff”, using as few chunks as necessary and without any leading
+// zeros. The empty Set is represented as “0”.
+//
+// See also [NewSetFromMask].
+func (s Set) MaskString() string {
+	return string(s.AppendMask(nil, 0))
+}
+
+// AppendMask appends the CPUs in this set in the kernel's hex mask format to
+// dst, returning the extended buffer.
+//
+// If nbits is zero, then as few chunks as necessary are emitted and without
+// leading zeros. Otherwise, AppendMask renders exactly the same text as the
+// kernel does for a bitmap of nbits bits: for instance, passing the number of
+// possible CPUs (nr_cpu_ids) renders the mask as it appears in procfs and sysfs
+// files. In this case, CPUs at or beyond nbits are ignored.
+func (s Set) AppendMask(dst []byte, nbits uint) []byte {
+	const hexdigits = "0123456789abcdef"
+
+	chunks := (nbits + maskChunkBits - 1) / maskChunkBits
+	// The kernel renders the most significant chunk only with as many hex
+	// digits as required by the bitmap size; all other chunks are always
+	// rendered with eight digits.
+	firstDigits := ((nbits-1)%maskChunkBits + 4) / 4
+	if nbits == 0 {
+		// Find the most significant non-zero chunk, if any, and then render
+		// it without leading zeros.
+		chunks = 1
+		for idx := uint(len(s)) * 2; idx > 0; idx-- {
+			if maskChunk(s, idx-1) != 0 {
+				chunks = idx
+				break
+			}
+		}
+		firstDigits = 1
+		for maskChunk(s, chunks-1)>>(firstDigits*4) != 0 {
+			firstDigits++
+		}
+	}
+	for idx := chunks; idx > 0; idx-- {
+		chunk := maskChunk(s, idx-1)
+		digits := uint(maskChunkBits / 4)
+		if idx == chunks {
+			digits = firstDigits
+			if nbits%maskChunkBits != 0 {
+				chunk &= 1<<(nbits%maskChunkBits) - 1
+			}
+		} else {
+			dst = append(dst, ',')
+		}
+		for digit := digits; digit > 0; digit-- {
+			dst = append(dst, hexdigits[(chunk>>((digit-1)*4))&0xf])
+		}
+	}
+	return dst
+}
+
+// maskChunk returns the 32 bit chunk with the specified index from the Set,
+// where chunk 0 represents CPUs 0–31.
+func maskChunk(s Set, idx uint) uint64 {
+	if idx/2 >= uint(len(s)) {
+		return 0
+	}
+	return (s[idx/2] >> ((idx % 2) * maskChunkBits)) & (1<<maskChunkBits - 1)
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"bytes"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("hex masks", func() {
+
+	DescribeTable("parsing",
+		func(text string, expected string) {
+			Expect(Successful(NewSetFromMask([]byte(text))).String()).To(Equal(expected))
+		},
+		Entry(nil, "", ""),
+		Entry(nil, "\n", ""),
+		Entry(nil, "0", ""),
+		Entry(nil, "f", "0-3"),
+		Entry(nil, "F0\n", "4-7"),
+		Entry(nil, "ff,ffffffff", "0-39"),
+		Entry(nil, "00000001,00000000", "32"),
+		Entry(nil, "1,0,0", "64"),
+		Entry(nil, "80000000,00000000,00000001", "0,95"),
+	)
+
+	DescribeTable("parsing errors",
+		func(text string, msg string) {
+			Expect(NewSetFromMask([]byte(text))).Error().To(MatchError(msg))
+		},
+		Entry(nil, ",ff", "expected hex digit"),
+		Entry(nil, "ff,", "expected hex digit"),
+		Entry(nil, "ff,,ff", "expected hex digit"),
+		Entry(nil, "123456789", "too many hex digits in chunk"),
+		Entry(nil, "0x1f", "expected hex digit or ','"),
+		Entry(nil, "ff\n\n", "expected hex digit or ','"),
+	)
+
+	DescribeTable("rendering",
+		func(list string, nbits int, expected string) {
+			s := Successful(NewList([]byte(list))).Set()
+			Expect(string(s.AppendMask([]byte("mask:"), uint(nbits)))).To(Equal("mask:" + expected))
+			if nbits == 0 {
+				Expect(s.MaskString()).To(Equal(expected))
+			}
+			Expect(Successful(NewSetFromMask([]byte(expected))).String()).To(Equal(list))
+		},
+		Entry(nil, "", 0, "0"),
+		Entry(nil, "0-3", 0, "f"),
+		Entry(nil, "0-39", 0, "ff,ffffffff"),
+		Entry(nil, "32", 0, "1,00000000"),
+		Entry(nil, "64", 0, "1,00000000,00000000"),
+		Entry(nil, "0", 4, "1"),
+		Entry(nil, "0", 8, "01"),
+		Entry(nil, "0", 32, "00000001"),
+		Entry(nil, "0", 40, "00,00000001"),
+		Entry(nil, "32", 64, "00000001,00000000"),
+		Entry(nil, "", 8, "00"),
+	)
+
+	It("ignores CPUs beyond the bitmap size", func() {
+		Expect(string(Set{0xff}.AppendMask(nil, 4))).To(Equal("f"))
+		Expect(string(Set{0x1, 0x1}.AppendMask(nil, 32))).To(Equal("00000001"))
+	})
+
+	It("round-trips this process's Cpus_allowed", func() {
+		var prefix = []byte("Cpus_allowed:\t")
+		var mask []byte
+		for line := range Lines(Successful(os.ReadFile("/proc/self/status"))) {
+			if bytes.HasPrefix(line, prefix) {
+				mask = line[len(prefix):]
+			}
+		}
+		Expect(mask).NotTo(BeEmpty())
+		s := Successful(NewSetFromMask(mask))
+		Expect(s.List()).To(Equal(Successful(Affinity(os.Getpid())).List()))
+		nbits := uint(len(bytes.ReplaceAll(bytes.TrimSpace(mask), []byte{','}, nil)) * 4)
+		Expect(string(s.AppendMask(nil, nbits)) + "\n").To(Equal(string(mask)))
+	})
+
+})
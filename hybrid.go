@@ -0,0 +1,161 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"cmp"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Names of the x86 hybrid core classes, as named by the kernel's PMUs.
+const (
+	PerformanceCoreClass = "cpu_core" // Intel performance cores (P-cores).
+	EfficiencyCoreClass  = "cpu_atom" // Intel efficiency cores (E-cores).
+)
+
+// CoreClass is a class of CPUs of the same core type or capacity.
+type CoreClass struct {
+	// Name is either [PerformanceCoreClass] or [EfficiencyCoreClass] on x86
+	// hybrid systems, and empty otherwise.
+	Name string
+	// Capacity is the highest relative compute capacity of the CPUs in this
+	// class, where the most capable CPUs of a system have a capacity of 1024.
+	// It is 0 if the kernel doesn't report CPU capacities.
+	Capacity uint
+	// CPUs are the online CPUs in this class.
+	CPUs List
+}
+
+// CoreClasses are the classes of the online CPUs of a system. Hybrid systems,
+// such as Intel systems with P- and E-cores or ARM big.LITTLE and DynamIQ
+// systems, have multiple classes; homogeneous systems have only a single
+// class.
+type CoreClasses struct {
+	// Classes are ordered from the most to the least performant class.
+	Classes []CoreClass
+}
+
+// CoreClasses returns the classification of the online CPUs of the system into
+// core types and capacities. Otherwise, it returns an error.
+//
+// On x86 hybrid systems the classification is based on the CPUs of the
+// “cpu_core” and “cpu_atom” PMUs in “/sys/devices”. Otherwise, the CPUs are
+// classified by their capacities, as found in the “cpu_capacity” files of the
+// CPUs on ARM and other systems with asymmetric CPU capacities. If neither
+// information is available, all online CPUs form a single class.
+func (fs Sysfs) CoreClasses() (*CoreClasses, error) {
+	online, err := fs.OnlineCPUs()
+	if err != nil {
+		return nil, err
+	}
+	capacities, err := fs.cpuCapacities(online)
+	if err != nil {
+		return nil, err
+	}
+	classes := &CoreClasses{}
+	for _, name := range []string{PerformanceCoreClass, EfficiencyCoreClass} {
+		cpus, err := fs.readOptionalList("devices", name, "cpus")
+		if err != nil {
+			return nil, err
+		}
+		if cpus = cpus.Overlap(online); len(cpus) == 0 {
+			continue
+		}
+		class := CoreClass{Name: name, CPUs: cpus}
+		for cpu := range cpus.All() {
+			class.Capacity = max(class.Capacity, capacities[cpu])
+		}
+		classes.Classes = append(classes.Classes, class)
+	}
+	if len(classes.Classes) != 0 {
+		return classes, nil
+	}
+	if len(capacities) == 0 {
+		classes.Classes = []CoreClass{{CPUs: online}}
+		return classes, nil
+	}
+	for cpu := range online.All() {
+		capacity := capacities[cpu]
+		idx := slices.IndexFunc(classes.Classes, func(class CoreClass) bool {
+			return class.Capacity == capacity
+		})
+		if idx < 0 {
+			classes.Classes = append(classes.Classes, CoreClass{Capacity: capacity})
+			idx = len(classes.Classes) - 1
+		}
+		classes.Classes[idx].CPUs = appendMerged(classes.Classes[idx].CPUs, [2]uint{cpu, cpu})
+	}
+	slices.SortFunc(classes.Classes, func(a, b CoreClass) int {
+		return cmp.Compare(b.Capacity, a.Capacity)
+	})
+	return classes, nil
+}
+
+// cpuCapacities returns the capacities of the specified CPUs, or nil if the
+// kernel doesn't report capacities for all of these CPUs.
+func (fs Sysfs) cpuCapacities(cpus List) (map[uint]uint, error) {
+	capacities := map[uint]uint{}
+	for cpu := range cpus.All() {
+		capacity, err := fs.readInt(filepath.Join(cpuDir(cpu), "cpu_capacity"))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if capacity < 0 {
+			capacity = 0
+		}
+		capacities[cpu] = uint(capacity)
+	}
+	return capacities, nil
+}
+
+// IsHybrid returns true if there are multiple classes of CPUs.
+func (c *CoreClasses) IsHybrid() bool {
+	return len(c.Classes) > 1
+}
+
+// Performance returns the CPUs of the most performant class. On homogeneous
+// systems these are all online CPUs.
+func (c *CoreClasses) Performance() List {
+	if len(c.Classes) == 0 {
+		return List{}
+	}
+	return c.Classes[0].CPUs
+}
+
+// Efficiency returns the CPUs of the least performant class. On homogeneous
+// systems, the List is empty.
+func (c *CoreClasses) Efficiency() List {
+	if !c.IsHybrid() {
+		return List{}
+	}
+	return c.Classes[len(c.Classes)-1].CPUs
+}
+
+// ClassOf returns the class of the specified CPU, or false if the CPU isn't
+// part of any class.
+func (c *CoreClasses) ClassOf(cpu uint) (*CoreClass, bool) {
+	for idx := range c.Classes {
+		if c.Classes[idx].CPUs.Contains(cpu) {
+			return &c.Classes[idx], true
+		}
+	}
+	return nil, false
+}
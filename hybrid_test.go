@@ -0,0 +1,148 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"fmt"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+// capacities returns the sysfs cpu_capacity files for the specified CPUs.
+func capacities(cpus List, capacity uint) sysfsFiles {
+	files := sysfsFiles{}
+	for cpu := range cpus.All() {
+		files[fmt.Sprintf("devices/system/cpu/cpu%d/cpu_capacity", cpu)] = fmt.Sprint(capacity)
+	}
+	return files
+}
+
+// x86HybridFixture is an Intel system with 6 P-cores with two hardware threads
+// each and 8 E-cores.
+var x86HybridFixture = sysfsFiles{
+	"devices/system/cpu/online": "0-19",
+	"devices/cpu_core/cpus":     "0-11",
+	"devices/cpu_atom/cpus":     "12-19",
+}
+
+// armFixture is an ARM DynamIQ system with 4 little, 3 big, and a single prime
+// core.
+var armFixture = []sysfsFiles{
+	{"devices/system/cpu/online": "0-7"},
+	capacities(List{{0, 3}}, 446),
+	capacities(List{{4, 6}}, 871),
+	capacities(List{{7, 7}}, 1024),
+}
+
+var _ = Describe("core classes", func() {
+
+	It("classifies x86 hybrid systems", func() {
+		classes := Successful(fakeSysfs(x86HybridFixture).CoreClasses())
+		Expect(classes.Classes).To(Equal([]CoreClass{
+			{Name: PerformanceCoreClass, CPUs: List{{0, 11}}},
+			{Name: EfficiencyCoreClass, CPUs: List{{12, 19}}},
+		}))
+		Expect(classes.IsHybrid()).To(BeTrue())
+		Expect(classes.Performance()).To(Equal(List{{0, 11}}))
+		Expect(classes.Efficiency()).To(Equal(List{{12, 19}}))
+		class, ok := classes.ClassOf(13)
+		Expect(ok).To(BeTrue())
+		Expect(class.Name).To(Equal(EfficiencyCoreClass))
+		Expect(classes.ClassOf(20)).Error().To(BeFalse())
+	})
+
+	It("classifies x86 hybrid systems with capacities and offline CPUs", func() {
+		classes := Successful(fakeSysfs(x86HybridFixture,
+			sysfsFiles{"devices/system/cpu/online": "0-15"},
+			capacities(List{{0, 11}}, 1024),
+			capacities(List{{12, 19}}, 580),
+		).CoreClasses())
+		Expect(classes.Classes).To(Equal([]CoreClass{
+			{Name: PerformanceCoreClass, Capacity: 1024, CPUs: List{{0, 11}}},
+			{Name: EfficiencyCoreClass, Capacity: 580, CPUs: List{{12, 15}}},
+		}))
+	})
+
+	It("classifies ARM systems by capacity", func() {
+		classes := Successful(fakeSysfs(armFixture...).CoreClasses())
+		Expect(classes.Classes).To(Equal([]CoreClass{
+			{Capacity: 1024, CPUs: List{{7, 7}}},
+			{Capacity: 871, CPUs: List{{4, 6}}},
+			{Capacity: 446, CPUs: List{{0, 3}}},
+		}))
+		Expect(classes.IsHybrid()).To(BeTrue())
+		Expect(classes.Performance()).To(Equal(List{{7, 7}}))
+		Expect(classes.Efficiency()).To(Equal(List{{0, 3}}))
+	})
+
+	It("classifies ARM systems with interleaved capacities", func() {
+		classes := Successful(fakeSysfs(
+			sysfsFiles{"devices/system/cpu/online": "0-5"},
+			capacities(List{{0, 1}, {4, 5}}, 512),
+			capacities(List{{2, 3}}, 1024),
+		).CoreClasses())
+		Expect(classes.Classes).To(Equal([]CoreClass{
+			{Capacity: 1024, CPUs: List{{2, 3}}},
+			{Capacity: 512, CPUs: List{{0, 1}, {4, 5}}},
+		}))
+	})
+
+	It("falls back on homogeneous systems", func() {
+		classes := Successful(fakeSysfs(
+			sysfsFiles{"devices/system/cpu/online": "0-3"},
+			capacities(List{{0, 3}}, 1024),
+		).CoreClasses())
+		Expect(classes.Classes).To(Equal([]CoreClass{
+			{Capacity: 1024, CPUs: List{{0, 3}}},
+		}))
+		Expect(classes.IsHybrid()).To(BeFalse())
+		Expect(classes.Performance()).To(Equal(List{{0, 3}}))
+		Expect(classes.Efficiency()).To(BeEmpty())
+
+		classes = Successful(fakeSysfs(
+			sysfsFiles{"devices/system/cpu/online": "0-3"},
+			capacities(List{{0, 2}}, 1024),
+		).CoreClasses())
+		Expect(classes.Classes).To(Equal([]CoreClass{{CPUs: List{{0, 3}}}}))
+
+		Expect((&CoreClasses{}).Performance()).To(BeEmpty())
+	})
+
+	It("reports errors", func() {
+		Expect(Sysfs(GinkgoT().TempDir()).CoreClasses()).Error().To(MatchError(os.ErrNotExist))
+		Expect(fakeSysfs(x86HybridFixture, sysfsFiles{
+			"devices/cpu_atom/cpus": "x",
+		}).CoreClasses()).Error().To(MatchError(ErrExpectedNumber))
+		Expect(fakeSysfs(append(armFixture, sysfsFiles{
+			"devices/system/cpu/cpu5/cpu_capacity": "x",
+		})...).CoreClasses()).Error().To(HaveOccurred())
+	})
+
+	It("classifies this system's CPUs", func() {
+		classes := Successful(DefaultSysfs.CoreClasses())
+		Expect(classes.Classes).NotTo(BeEmpty())
+		all := List{}
+		for _, class := range classes.Classes {
+			Expect(class.CPUs).NotTo(BeEmpty())
+			Expect(all.IsOverlapping(class.CPUs)).To(BeFalse())
+			all = all.Union(class.CPUs)
+		}
+		Expect(classes.Performance()).NotTo(BeEmpty())
+	})
+
+})
@@ -0,0 +1,104 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// unixCPUSetSize is the number of CPUs a fixed-size [unix.CPUSet] can hold.
+const unixCPUSetSize = uint(unsafe.Sizeof(unix.CPUSet{}) * 8)
+
+// FromUnixCPUSet returns a new Set with the CPUs from the specified
+// [unix.CPUSet], as used by [unix.SchedGetaffinity] and libraries based on
+// golang.org/x/sys/unix. The returned Set has no trailing zero words.
+func FromUnixCPUSet(cpuset *unix.CPUSet) Set {
+	// Depending on the platform, the unix.CPUSet words are either 32 or 64
+	// bits wide, so we copy them into our 64 bit words accordingly.
+	const cpusetwordbits = uint(unsafe.Sizeof(cpuset[0]) * 8)
+	s := make(Set, setBitIndex(unixCPUSetSize-1)+1)
+	for idx, word := range cpuset {
+		cpu := uint(idx) * cpusetwordbits
+		s[setBitIndex(cpu)] |= uint64(word) << (cpu % bitsperword)
+	}
+	return s.Compact()
+}
+
+// UnixCPUSet returns the [unix.CPUSet] corresponding with this Set. If this Set
+// contains CPUs beyond the fixed size of unix.CPUSet, then [ErrBeyondCapacity]
+// is returned instead.
+func (s Set) UnixCPUSet() (unix.CPUSet, error) {
+	var cpuset unix.CPUSet
+	if cpu, ok := s.Max(); ok && cpu >= unixCPUSetSize {
+		return unix.CPUSet{}, fmt.Errorf("%w: unix.CPUSet holds only %d CPUs, found CPU %d",
+			ErrBeyondCapacity, unixCPUSetSize, cpu)
+	}
+	const cpusetwordbits = uint(unsafe.Sizeof(cpuset[0]) * 8)
+	for idx := range cpuset {
+		cpu := uint(idx) * cpusetwordbits
+		wordidx := setBitIndex(cpu)
+		if wordidx >= len(s) {
+			break
+		}
+		setCPUSetWord(&cpuset[idx], s[wordidx]>>(cpu%bitsperword))
+	}
+	return cpuset, nil
+}
+
+// setCPUSetWord sets the specified [unix.CPUSet] word, which is either 32 or 64
+// bits wide depending on the platform, to the lower bits of the specified Set
+// word.
+func setCPUSetWord[W ~uint32 | ~uint64](word *W, bits uint64) {
+	*word = W(bits)
+}
+
+// FromSlice returns a new List in canonical form with the CPUs from the
+// specified slice of CPU numbers, in any order and with duplicates allowed. If
+// the slice contains negative CPU numbers, then [ErrNegativeCPU] is returned
+// instead.
+//
+// FromSlice allows interoperating with APIs representing CPU sets as integer
+// slices, such as k8s.io/utils/cpuset.
+func FromSlice(cpus []int) (List, error) {
+	for _, cpu := range cpus {
+		if cpu < 0 {
+			return nil, fmt.Errorf("%w %d", ErrNegativeCPU, cpu)
+		}
+	}
+	return CollectList(func(yield func(uint) bool) {
+		for _, cpu := range cpus {
+			if !yield(uint(cpu)) {
+				return
+			}
+		}
+	}), nil
+}
+
+// Slice returns the CPUs in this List as a slice of CPU numbers, in the order
+// of the List's ranges. See also [FromSlice].
+//
+// As Slice enumerates each individual CPU, it must not be used on Lists from
+// untrusted sources without bounding them first, such as using [WithMaxCPU]
+// when parsing.
+func (l List) Slice() []int {
+	cpus := make([]int, 0, min(l.Count(), MaxCPUs))
+	for cpu := range l.All() {
+		cpus = append(cpus, int(cpu))
+	}
+	return cpus
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("interoperability", func() {
+
+	When("converting from and to unix.CPUSets", func() {
+
+		DescribeTable("round-tripping",
+			func(text string) {
+				s := Successful(NewSet([]byte(text)))
+				cpuset := Successful(s.UnixCPUSet())
+				Expect(cpuset.Count()).To(Equal(s.Count()))
+				for cpu := range s.All() {
+					Expect(cpuset.IsSet(int(cpu))).To(BeTrue())
+				}
+				Expect(FromUnixCPUSet(&cpuset).String()).To(Equal(text))
+			},
+			Entry(nil, ""),
+			Entry(nil, "0"),
+			Entry(nil, "1-3,5,63-64"),
+			Entry(nil, "0-1023"),
+			Entry(nil, "31-32,63-64,95-96"),
+			Entry(nil, "1000-1023"),
+		)
+
+		It("returns compacted sets", func() {
+			var cpuset unix.CPUSet
+			cpuset.Set(65)
+			Expect(FromUnixCPUSet(&cpuset)).To(Equal(Set{0, 0x2}))
+			Expect(FromUnixCPUSet(&unix.CPUSet{})).To(BeEmpty())
+		})
+
+		It("rejects CPUs beyond the fixed size", func() {
+			var s Set
+			s.Add(unixCPUSetSize)
+			Expect(s.UnixCPUSet()).Error().To(MatchError(ErrBeyondCapacity))
+		})
+
+		It("ignores trailing zero words beyond the fixed size", func() {
+			s := make(Set, 2*setBitIndex(unixCPUSetSize))
+			s.Add(3)
+			s.Add(unixCPUSetSize - 1)
+			cpuset := Successful(s.UnixCPUSet())
+			Expect(cpuset.Count()).To(Equal(2))
+			Expect(cpuset.IsSet(int(unixCPUSetSize - 1))).To(BeTrue())
+		})
+
+		It("converts this process's affinity from unix.SchedGetaffinity", func() {
+			var cpuset unix.CPUSet
+			Expect(unix.SchedGetaffinity(0, &cpuset)).To(Succeed())
+			Expect(FromUnixCPUSet(&cpuset).List()).To(Equal(Successful(Affinity(0)).List()))
+		})
+
+	})
+
+	When("converting from and to integer slices", func() {
+
+		DescribeTable("converting from slices",
+			func(cpus []int, expected string) {
+				Expect(Successful(FromSlice(cpus)).String()).To(Equal(expected))
+			},
+			Entry(nil, nil, ""),
+			Entry(nil, []int{42}, "42"),
+			Entry(nil, []int{1, 2, 3, 5}, "1-3,5"),
+			Entry(nil, []int{5, 3, 1, 2, 3}, "1-3,5"),
+		)
+
+		It("rejects negative CPU numbers", func() {
+			Expect(FromSlice([]int{1, -1})).Error().To(SatisfyAll(
+				MatchError(ErrNegativeCPU),
+				MatchError("invalid negative CPU number -1")))
+		})
+
+		It("converts to slices", func() {
+			Expect(List{}.Slice()).To(BeEmpty())
+			cpus := List{{1, 3}, {5, 5}}.Slice()
+			Expect(cpus).To(Equal([]int{1, 2, 3, 5}))
+			Expect(cap(cpus)).To(Equal(4))
+			Expect(List{{0, MaxCPUs}}.Slice()).To(HaveLen(MaxCPUs + 1))
+		})
+
+	})
+
+})
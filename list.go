@@ -15,39 +15,62 @@
 package cpus
 
 import (
-	"errors"
+	"bytes"
+	"cmp"
 	"fmt"
-	"strings"
+	"iter"
+	"math"
+	"os"
+	"strconv"
 
 	"slices"
-
-	"github.com/thediveo/faf"
 )
 
 // List is a list of CPU [from...to] ranges. CPU numbers are starting from zero.
 //
 // The behavior of operations on Lists that are not in canonical form is
-// undefined. In the canonical form of a List all its ranges are non-overlapping
-// and in each range the first “from” element must be less or equal to the
-// second “to” element.
+// undefined. In the canonical form of a List all its ranges are sorted in
+// ascending order, neither overlapping nor adjacent, and in each range the
+// first “from” element must be less or equal to the second “to” element. Use
+// [List.Normalize] to turn an arbitrary List into its canonical form and
+// [List.Validate] to check for the canonical form.
 type List [][2]uint
 
 // String returns the CPU list in textual format, with the individual ranges
 // “x-y” separated by “,” and single CPU ranges collapsed into single CPU
 // numbers “x” instead of an “x-x” range.
 func (l List) String() string {
-	var b strings.Builder
-	for idx, cpurange := range l {
+	var buf [64]byte
+	return string(l.appendText(buf[:0]))
+}
+
+// AppendText appends the CPU list in textual format, as returned by
+// [List.String], to dst, returning the extended buffer. AppendText doesn't
+// allocate when dst has sufficient capacity.
+func (l List) AppendText(dst []byte) ([]byte, error) {
+	return l.appendText(dst), nil
+}
+
+// appendText appends the CPU list in textual format to dst.
+func (l List) appendText(dst []byte) []byte {
+	for idx, r := range l {
 		if idx > 0 {
-			b.WriteString(",")
-		}
-		if cpurange[0] == cpurange[1] {
-			b.WriteString(fmt.Sprintf("%d", cpurange[0]))
-			continue
+			dst = append(dst, ',')
 		}
-		b.WriteString(fmt.Sprintf("%d-%d", cpurange[0], cpurange[1]))
+		dst = appendRange(dst, r[0], r[1])
 	}
-	return b.String()
+	return dst
+}
+
+// appendRange appends the specified CPU range in textual format to dst,
+// collapsing single CPU ranges into just the CPU number.
+func appendRange(dst []byte, from, to uint) []byte {
+	dst = strconv.AppendUint(dst, uint64(from), 10)
+	if from == to {
+		return dst
+	}
+	dst = append(dst, '-')
+	return strconv.AppendUint(dst, uint64(to), 10)
 }
 
 // NewList returns a new CPU List for the given text. If the text is malformed
@@ -70,74 +93,258 @@ func (l List) String() string {
 //   - “666,,42” (invalid repeated comma)
 //   - “42-” (missing range end)
 //   - “42,foobar” (garbage)
+//
+// Malformed text is reported as a [*ParseError], telling the byte offset and
+// offending token, as well as the kind of error, such as [ErrExpectedNumber].
 func NewList(text []byte) (List, error) {
-	bstr := faf.NewBytestring(text)
 	// nota bene: not using make(...) saves us somehow 3 allocs overall and
 	// decreases memory consumption. compiler optimization??
 	l := List{}
-	for {
-		// nothing more, we're at the end of text/line, so we're successfully
-		// done.
-		if bstr.EOL() {
-			return l, nil
+	err := parseList(text, &parseOptions{}, func(from, to uint) {
+		l = append(l, [2]uint{from, to})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// ParseListLenient returns a new CPU List for the given text, ignoring any
+// leading and trailing white space, including newlines. Otherwise, it accepts
+// the same list format as [NewList].
+//
+// ParseListLenient is useful for parsing CPU lists read from procfs and sysfs
+// files, which end with a trailing newline.
+func ParseListLenient(text []byte) (List, error) {
+	return NewList(bytes.TrimSpace(text))
+}
+
+// ReadList returns a new CPU List read from the file with the specified path.
+// It ignores leading and trailing white space, as described in
+// [ParseListLenient]. An empty file returns an empty List. If the file cannot be
+// read or its contents are malformed, ReadList returns an error that includes
+// the file path.
+func ReadList(path string) (List, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CPU list, %w", err)
+	}
+	l, err := ParseListLenient(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CPU list in %q, %w", path, err)
+	}
+	return l, nil
+}
+
+// Normalize returns a new List in canonical form with the same CPUs as this
+// List: its ranges are sorted in ascending order and overlapping as well as
+// adjacent ranges are merged. For instance, “5,1-3,2-4” becomes “1-5”.
+// Inverted ranges are taken as if their “from” and “to” elements were swapped.
+func (l List) Normalize() List {
+	sorted := make(List, 0, len(l))
+	for _, r := range l {
+		if r[0] > r[1] {
+			r[0], r[1] = r[1], r[0]
 		}
-		// we now expect a CPU number and if there is nothing else following,
-		// we're also done, adding the CPU number as a single CPU range to our
-		// list.
-		from, ok := bstr.Uint64()
-		if !ok {
-			return nil, errors.New("expected unsigned integer number")
+		sorted = append(sorted, r)
+	}
+	slices.SortFunc(sorted, func(a, b [2]uint) int {
+		return cmp.Compare(a[0], b[0])
+	})
+	normalized := List{}
+	for _, r := range sorted {
+		normalized = appendMerged(normalized, r)
+	}
+	return normalized
+}
+
+// IsCanonical returns true if this List is in canonical form, otherwise false.
+func (l List) IsCanonical() bool {
+	return l.Validate() == nil
+}
+
+// Validate returns nil if this List is in canonical form. Otherwise, it returns
+// an error telling the first range breaking the canonical form, and why.
+func (l List) Validate() error {
+	for idx, r := range l {
+		if r[0] > r[1] {
+			return fmt.Errorf("range #%d %d-%d is inverted", idx, r[0], r[1])
 		}
-		if bstr.EOL() {
-			return append(l, [2]uint{uint(from), uint(from)}), nil
+		if idx == 0 {
+			continue
 		}
-		// Either this is a from-to range or another range should follow...
-		switch ch, _ := bstr.Next(); ch {
-		case '-':
-			// a range, so get the end of the range and then add the range to
-			// our list. If nothing else follows, then we're done.
-			to, ok := bstr.Uint64()
-			if !ok {
-				return nil, errors.New("expected unsigned integer number")
-			}
-			if from > to {
-				return nil, errors.New("invalid inverted range")
-			}
-			l = append(l, [2]uint{uint(from), uint(to)})
-			if bstr.EOL() {
-				return l, nil
-			}
-			// another CPU number (or range) is expected to follow, separated by
-			// ",", so we check for a necessary comma. Then we start over with
-			// the next CPU number or range.
-			ch, _ = bstr.Next()
-			if ch != ',' {
-				return nil, errors.New("expected ','")
-			}
-		case ',':
-			// a single CPU number, and more to follow; so add this single CPU
-			// range and then rinse and repeat.
-			l = append(l, [2]uint{uint(from), uint(from)})
-		default:
-			return nil, errors.New("expected '-' or ','")
+		// Take care to not overflow when the previous range ends at the very
+		// end of the CPU number space.
+		if prev := l[idx-1]; prev[1] == ^uint(0) || r[0] <= prev[1]+1 {
+			return fmt.Errorf("range #%d %d-%d overlaps, adjoins, or precedes range #%d %d-%d",
+				idx, r[0], r[1], idx-1, prev[0], prev[1])
 		}
 	}
+	return nil
 }
 
+// List returns this List itself, satisfying the [CPUs] interface.
+func (l List) List() List { return l }
+
 // Set returns the CPU Set corresponding with this list.
 func (l List) Set() Set {
 	if len(l) == 0 {
 		return Set{}
 	}
-	// Do last range first to allocate only once.
-	var s Set
-	for i := range l {
-		r := l[len(l)-i-1]
-		s = s.AddRange(r[0], r[1])
+	s := make(Set, setBitIndex(l[len(l)-1][1])+1)
+	for _, r := range l {
+		s.fillRange(r[0], r[1])
 	}
 	return s
 }
 
+// All returns an iterator over the individual CPUs in this List, in the order
+// of the List's ranges.
+func (l List) All() iter.Seq[uint] {
+	return func(yield func(cpu uint) bool) {
+		for _, r := range l {
+			for cpu := r[0]; ; cpu++ {
+				if !yield(cpu) {
+					return
+				}
+				if cpu == r[1] {
+					break
+				}
+			}
+		}
+	}
+}
+
+// Ranges returns an iterator over the ranges in this List, yielding the first
+// and last CPU of each range.
+func (l List) Ranges() iter.Seq2[uint, uint] {
+	return func(yield func(from, to uint) bool) {
+		for _, r := range l {
+			if !yield(r[0], r[1]) {
+				return
+			}
+		}
+	}
+}
+
+// CollectList returns a new List in canonical form with the CPUs from the
+// specified iterator. CPUs are best passed in ascending order, as otherwise the
+// List needs to be normalized after collecting all CPUs.
+func CollectList(cpus iter.Seq[uint]) List {
+	l := List{}
+	ordered := true
+	for cpu := range cpus {
+		if last := len(l) - 1; last >= 0 {
+			if cpu <= l[last][1] {
+				ordered = ordered && cpu >= l[last][0]
+				if ordered {
+					continue // already in the last range
+				}
+			} else if cpu == l[last][1]+1 {
+				l[last][1] = cpu
+				continue
+			}
+		}
+		l = append(l, [2]uint{cpu, cpu})
+	}
+	if !ordered {
+		return l.Normalize()
+	}
+	return l
+}
+
+// Count returns the number of CPUs in this List. For Lists with more CPUs than
+// an int can count, Count saturates at [math.MaxInt].
+func (l List) Count() int {
+	count := uint(0)
+	for _, r := range l {
+		// Please note that the size of a range spanning the full CPU number
+		// space wraps around to zero.
+		size := r[1] - r[0] + 1
+		if size == 0 || size > math.MaxInt-count {
+			return math.MaxInt
+		}
+		count += size
+	}
+	return int(count)
+}
+
+// Min returns the lowest CPU in this List and true, otherwise false if the List
+// is empty.
+func (l List) Min() (cpu uint, ok bool) {
+	if len(l) == 0 {
+		return 0, false
+	}
+	return l[0][0], true
+}
+
+// Max returns the highest CPU in this List and true, otherwise false if the
+// List is empty.
+func (l List) Max() (cpu uint, ok bool) {
+	if len(l) == 0 {
+		return 0, false
+	}
+	return l[len(l)-1][1], true
+}
+
+// Nth returns the CPU with the 0-based logical index n in this List and true.
+// If there are only n or less CPUs in this List, Nth returns false. For
+// instance, Nth(2) returns the third CPU in this List.
+func (l List) Nth(n int) (cpu uint, ok bool) {
+	if n < 0 {
+		return 0, false
+	}
+	for _, r := range l {
+		if size := r[1] - r[0] + 1; uint(n) >= size && size != 0 {
+			n -= int(size)
+			continue
+		}
+		return r[0] + uint(n), true
+	}
+	return 0, false
+}
+
+// IndexOf returns the 0-based logical index of the specified CPU within this
+// List and true. If the CPU is not in this List, or its index exceeds
+// [math.MaxInt], IndexOf returns false instead. IndexOf is the inverse of
+// [List.Nth].
+func (l List) IndexOf(cpu uint) (n int, ok bool) {
+	ridx, ok := l.search(cpu)
+	if !ok {
+		return 0, false
+	}
+	idx := cpu - l[ridx][0]
+	if idx > math.MaxInt {
+		return 0, false
+	}
+	for _, r := range l[:ridx] {
+		// Ranges before the one containing the CPU cannot span the full CPU
+		// number space, so their sizes cannot wrap around to zero.
+		size := r[1] - r[0] + 1
+		if size > math.MaxInt-idx {
+			return 0, false
+		}
+		idx += size
+	}
+	return int(idx), true
+}
+
+// Contains returns true if the specified CPU is in this List, otherwise false.
+// Contains does a binary search over the ranges of this List.
+func (l List) Contains(cpu uint) bool {
+	_, ok := l.search(cpu)
+	return ok
+}
+
+// search returns the index of the range containing the specified CPU and true,
+// otherwise false.
+func (l List) search(cpu uint) (int, bool) {
+	ridx, _ := slices.BinarySearchFunc(l, cpu, func(r [2]uint, cpu uint) int {
+		return cmp.Compare(r[1], cpu)
+	})
+	return ridx, ridx < len(l) && l[ridx][0] <= cpu
+}
+
 // IsOverlapping returns true if this List overlaps with another List.
 //
 // Both lists must be in canonical form where all ranges are ordered from lowest
@@ -204,6 +411,100 @@ func (l List) Overlap(another List) List {
 	return overlaps
 }
 
+// Union returns the union of this List with another List as a new List in
+// canonical form, with overlapping as well as adjacent ranges merged.
+//
+// Both lists must be in canonical form.
+func (l List) Union(another List) List {
+	union := List{}
+	r1idx, r2idx := 0, 0
+	for r1idx < len(l) || r2idx < len(another) {
+		// Always pick the range with the lower start from either list, so that
+		// we only ever need to either merge it with the last range in our
+		// union or append it.
+		var r [2]uint
+		if r2idx >= len(another) || (r1idx < len(l) && l[r1idx][0] <= another[r2idx][0]) {
+			r = l[r1idx]
+			r1idx++
+		} else {
+			r = another[r2idx]
+			r2idx++
+		}
+		union = appendMerged(union, r)
+	}
+	return union
+}
+
+// appendMerged appends the range r to the specified list, merging it with the
+// list's last range if both overlap or are adjacent. The range r must not start
+// before the last range of the list.
+func appendMerged(l List, r [2]uint) List {
+	if last := len(l) - 1; last >= 0 && (l[last][1] == ^uint(0) || r[0] <= l[last][1]+1) {
+		l[last][1] = max(l[last][1], r[1])
+		return l
+	}
+	return append(l, r)
+}
+
+// Difference returns a new List with the CPUs from this List that are not in
+// another List.
+//
+// Both lists must be in canonical form.
+func (l List) Difference(another List) List {
+	diff := List{}
+	r2idx := 0
+	for _, r1 := range l {
+		from := r1[0]
+		for {
+			// Skip all ranges from the second list that end before the
+			// remaining part of the current first range starts.
+			for r2idx < len(another) && another[r2idx][1] < from {
+				r2idx++
+			}
+			// If there are no more ranges in the second list, or the next one
+			// starts only after the current first range, then what remains of
+			// the current first range goes into the difference as it is.
+			if r2idx >= len(another) || another[r2idx][0] > r1[1] {
+				diff = append(diff, [2]uint{from, r1[1]})
+				break
+			}
+			// Keep the part of the current first range that lies before the
+			// current second range, if any.
+			r2 := another[r2idx]
+			if r2[0] > from {
+				diff = append(diff, [2]uint{from, r2[0] - 1})
+			}
+			// If the current second range also covers the end of the current
+			// first range, we're done with this first range. We don't advance
+			// in the second list, as its current range might also overlap with
+			// the next range of the first list.
+			if r2[1] >= r1[1] {
+				break
+			}
+			from = r2[1] + 1
+			r2idx++
+		}
+	}
+	return diff
+}
+
+// SymmetricDifference returns a new List with the CPUs that are either in this
+// List or in another List, but not in both.
+//
+// Both lists must be in canonical form.
+func (l List) SymmetricDifference(another List) List {
+	return l.Difference(another).Union(another.Difference(l))
+}
+
+// Complement returns a new List with the CPUs from the specified universe that
+// are not in this List. Typically, the universe will be the list of possible or
+// online CPUs.
+//
+// Both lists must be in canonical form.
+func (l List) Complement(universe List) List {
+	return universe.Difference(l)
+}
+
 // Remove the lowest CPU from the specified List, returning the CPU number
 // together with a new List of remaining CPUs.
 //
@@ -15,6 +15,14 @@
 package cpus
 
 import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
 	. "github.com/onsi/ginkgo/v2/dsl/core"
 	. "github.com/onsi/ginkgo/v2/dsl/table"
 	. "github.com/onsi/gomega"
@@ -30,8 +38,21 @@ var _ = Describe("cpu lists", func() {
 		Entry(nil, List{{1, 1}, {2, 42}, {666, 666}}, "1,2-42,666"),
 		Entry(nil, List{{2, 42}}, "2-42"),
 		Entry(nil, List{{2, 42}, {777, 778}}, "2-42,777-778"),
+		Entry(nil, List{}, ""),
 	)
 
+	It("appends textual representations without allocations", func() {
+		l := List{{1, 1}, {2, 42}, {666, 666}}
+		Expect(l.AppendText([]byte("cpus: "))).To(Equal([]byte("cpus: 1,2-42,666")))
+		buf := make([]byte, 0, 64)
+		Expect(testing.AllocsPerRun(10, func() {
+			_, _ = l.AppendText(buf[:0])
+		})).To(BeZero())
+		Expect(testing.AllocsPerRun(10, func() {
+			_ = l.String()
+		})).To(BeNumerically("<=", 1))
+	})
+
 	When("parsing lists from text", func() {
 
 		It("returns nothing from nothing", func() {
@@ -56,19 +77,129 @@ var _ = Describe("cpu lists", func() {
 		})
 
 		DescribeTable("parsing errors",
-			func(s string, msg string) {
-				Expect(NewList([]byte(s))).Error().To(MatchError(msg))
+			func(s string, kind error, offset int, token string, msg string) {
+				_, err := NewList([]byte(s))
+				Expect(err).To(MatchError(kind))
+				Expect(err).To(MatchError(msg))
+				var perr *ParseError
+				Expect(errors.As(err, &perr)).To(BeTrue())
+				Expect(perr.Input).To(Equal(s))
+				Expect(perr.Offset).To(Equal(offset))
+				Expect(perr.Token).To(Equal(token))
+			},
+			Entry(nil, "abc", ErrExpectedNumber, 0, "abc",
+				`expected unsigned integer number at offset 0, found "abc"`),
+			Entry(nil, "0abc", ErrExpectedSeparator, 1, "abc",
+				`expected separator at offset 1, found "abc"`),
+			Entry(nil, "42-", ErrExpectedNumber, 3, "",
+				"expected unsigned integer number at offset 3, found end of input"),
+			Entry(nil, "1-z", ErrExpectedNumber, 2, "z",
+				`expected unsigned integer number at offset 2, found "z"`),
+			Entry(nil, "0-0abc", ErrExpectedSeparator, 3, "abc",
+				`expected separator at offset 3, found "abc"`),
+			Entry(nil, "1,2,666-42", ErrInvertedRange, 8, "42",
+				`invalid inverted range at offset 8, found "42"`),
+			Entry(nil, "666,", ErrExpectedNumber, 4, "",
+				"expected unsigned integer number at offset 4, found end of input"),
+			Entry(nil, "666,,42", ErrExpectedNumber, 4, ",",
+				`expected unsigned integer number at offset 4, found ","`),
+			Entry(nil, "1-3\n", ErrExpectedSeparator, 3, "\n",
+				`expected separator at offset 3, found "\n"`),
+			Entry(nil, "0-99999999999999999999999", ErrCPUOutOfRange, 2, "99999999999999999999999",
+				`CPU number out of range at offset 2, found "99999999999999999999999"`),
+			Entry(nil, "0-7:1/2", ErrExpectedSeparator, 3, ":",
+				`expected separator at offset 3, found ":"`),
+			Entry(nil, "all", ErrExpectedNumber, 0, "all",
+				`expected unsigned integer number at offset 0, found "all"`),
+			Entry(nil, "0-N", ErrExpectedNumber, 2, "N",
+				`expected unsigned integer number at offset 2, found "N"`),
+		)
+
+	})
+
+	When("parsing lists leniently", func() {
+
+		DescribeTable("ignoring surrounding white space",
+			func(text string, expected List) {
+				Expect(ParseListLenient([]byte(text))).To(Equal(expected))
 			},
-			Entry(nil, "abc", "expected unsigned integer number"),
-			Entry(nil, "0abc", "expected '-' or ','"),
-			Entry(nil, "42-", "expected unsigned integer number"),
-			Entry(nil, "1-z", "expected unsigned integer number"),
-			Entry(nil, "0-0abc", "expected ','"),
-			Entry(nil, "666-42", "invalid inverted range"),
+			Entry(nil, "", List{}),
+			Entry(nil, "\n", List{}),
+			Entry(nil, "1-3,5\n", List{{1, 3}, {5, 5}}),
+			Entry(nil, " \t42 \n\n", List{{42, 42}}),
 		)
 
+		It("rejects malformed lists", func() {
+			Expect(ParseListLenient([]byte("1-3, 5\n"))).Error().To(MatchError(ErrExpectedNumber))
+		})
+
+		It("reads lists from files", func() {
+			dir := GinkgoT().TempDir()
+			path := filepath.Join(dir, "cpus")
+
+			Expect(os.WriteFile(path, []byte("0-7,9\n"), 0o644)).To(Succeed())
+			Expect(ReadList(path)).To(Equal(List{{0, 7}, {9, 9}}))
+
+			Expect(os.WriteFile(path, nil, 0o644)).To(Succeed())
+			Expect(ReadList(path)).To(Equal(List{}))
+
+			Expect(os.WriteFile(path, []byte("0-7,\n"), 0o644)).To(Succeed())
+			Expect(ReadList(path)).Error().To(SatisfyAll(
+				MatchError(ErrExpectedNumber),
+				MatchError(ContainSubstring(path))))
+
+			Expect(ReadList(filepath.Join(dir, "nada"))).Error().To(SatisfyAll(
+				MatchError(os.ErrNotExist),
+				MatchError(ContainSubstring(filepath.Join(dir, "nada")))))
+		})
+
+		It("reads this system's online CPUs", func() {
+			Expect(ReadList("/sys/devices/system/cpu/online")).NotTo(BeEmpty())
+		})
+
+	})
+
+	DescribeTable("normalizing",
+		func(l List, expected string) {
+			n := l.Normalize()
+			Expect(n.String()).To(Equal(expected))
+			Expect(n.IsCanonical()).To(BeTrue())
+		},
+		Entry(nil, nil, ""),
+		Entry(nil, List{{42, 42}}, "42"),
+		Entry(nil, List{{5, 5}, {1, 3}, {2, 4}}, "1-5"),
+		Entry(nil, List{{1, 3}, {4, 4}}, "1-4"),
+		Entry(nil, List{{10, 12}, {1, 3}, {5, 6}}, "1-3,5-6,10-12"),
+		Entry(nil, List{{3, 1}, {7, 7}}, "1-3,7"),
+		Entry(nil, List{{1, 10}, {2, 3}, {4, 5}}, "1-10"),
+	)
+
+	It("doesn't modify the List when normalizing", func() {
+		l := List{{5, 5}, {1, 3}}
+		_ = l.Normalize()
+		Expect(l).To(Equal(List{{5, 5}, {1, 3}}))
 	})
 
+	DescribeTable("validating canonical form",
+		func(l List, msg string) {
+			if msg == "" {
+				Expect(l.Validate()).To(Succeed())
+				Expect(l.IsCanonical()).To(BeTrue())
+				return
+			}
+			Expect(l.Validate()).To(MatchError(msg))
+			Expect(l.IsCanonical()).To(BeFalse())
+		},
+		Entry(nil, nil, ""),
+		Entry(nil, List{{1, 3}, {5, 5}}, ""),
+		Entry(nil, List{{1, 3}, {4, 4}}, "range #1 4-4 overlaps, adjoins, or precedes range #0 1-3"),
+		Entry(nil, List{{1, 3}, {3, 4}}, "range #1 3-4 overlaps, adjoins, or precedes range #0 1-3"),
+		Entry(nil, List{{5, 5}, {1, 3}}, "range #1 1-3 overlaps, adjoins, or precedes range #0 5-5"),
+		Entry(nil, List{{0, ^uint(0)}, {0, 0}}, "range #1 0-0 overlaps, adjoins, or precedes range #0 0-"+
+			strconv.FormatUint(uint64(^uint(0)), 10)),
+		Entry(nil, List{{1, 1}, {4, 3}}, "range #1 4-3 is inverted"),
+	)
+
 	It("converts a list into a set", func() {
 		Expect(List{}.Set().String()).To(BeEmpty())
 		Expect(Successful(NewList([]byte("3,5,666"))).Set().String()).To(Equal("3,5,666"))
@@ -102,6 +233,136 @@ var _ = Describe("cpu lists", func() {
 		Entry(nil, "2-3,5-7,19-22", "1-20", "2-3,5-7,19-20"),
 	)
 
+	DescribeTable("calculating union",
+		func(l1, l2 string, union string) {
+			Expect(Successful(NewList([]byte(l1))).Union(
+				Successful(NewList([]byte(l2)))).String()).To(Equal(union))
+		},
+		Entry(nil, "", "", ""),
+		Entry(nil, "1-3", "", "1-3"),
+		Entry(nil, "", "5-7", "5-7"),
+		Entry(nil, "1-3", "5-7", "1-3,5-7"),
+		Entry(nil, "1-3", "4-7", "1-7"),
+		Entry(nil, "1-20", "2-3,5-7,19-22", "1-22"),
+		Entry(nil, "0,2,4", "1,3,6", "0-4,6"),
+		Entry(nil, "10-20,30-40", "15-35,50", "10-40,50"),
+	)
+
+	It("unites ranges at the end of the CPU number space", func() {
+		maxcpu := ^uint(0)
+		Expect(List{{maxcpu - 1, maxcpu}}.Union(List{{maxcpu, maxcpu}})).To(
+			Equal(List{{maxcpu - 1, maxcpu}}))
+	})
+
+	DescribeTable("calculating difference",
+		func(l1, l2 string, diff string) {
+			Expect(Successful(NewList([]byte(l1))).Difference(
+				Successful(NewList([]byte(l2)))).String()).To(Equal(diff))
+		},
+		Entry(nil, "", "", ""),
+		Entry(nil, "1-3", "", "1-3"),
+		Entry(nil, "", "5-7", ""),
+		Entry(nil, "1-3", "5-7", "1-3"),
+		Entry(nil, "1-20", "2-3,5-7,19-22", "1,4,8-18"),
+		Entry(nil, "1-3,5-7,9-11", "2-10", "1,11"),
+		Entry(nil, "1-3,5-7", "0-100", ""),
+		Entry(nil, "0-127", "0,64,127", "1-63,65-126"),
+	)
+
+	DescribeTable("calculating symmetric difference",
+		func(l1, l2 string, symdiff string) {
+			Expect(Successful(NewList([]byte(l1))).SymmetricDifference(
+				Successful(NewList([]byte(l2)))).String()).To(Equal(symdiff))
+		},
+		Entry(nil, "", "", ""),
+		Entry(nil, "1-3", "", "1-3"),
+		Entry(nil, "", "5-7", "5-7"),
+		Entry(nil, "1-5", "3-9", "1-2,6-9"),
+		Entry(nil, "1-3", "4-6", "1-6"),
+		Entry(nil, "1-3,7", "1-3", "7"),
+	)
+
+	DescribeTable("calculating complement",
+		func(l, universe string, complement string) {
+			Expect(Successful(NewList([]byte(l))).Complement(
+				Successful(NewList([]byte(universe)))).String()).To(Equal(complement))
+		},
+		Entry(nil, "", "", ""),
+		Entry(nil, "", "0-7", "0-7"),
+		Entry(nil, "1-3", "0-7", "0,4-7"),
+		Entry(nil, "0-7", "0-7", ""),
+		Entry(nil, "6-10", "0-7", "0-5"),
+	)
+
+	It("saturates counting at the end of the CPU number space", func() {
+		maxcpu := ^uint(0)
+		Expect(List{{0, maxcpu}}.Count()).To(Equal(math.MaxInt))
+		Expect(List{{0, maxcpu - 1}}.Count()).To(Equal(math.MaxInt))
+		Expect(List{{0, 0}, {2, maxcpu}}.Count()).To(Equal(math.MaxInt))
+		Expect(List{{0, math.MaxInt - 1}}.Count()).To(Equal(math.MaxInt))
+		Expect(List{{1, math.MaxInt - 1}}.Count()).To(Equal(math.MaxInt - 1))
+	})
+
+	It("refuses indices beyond what an int can count", func() {
+		maxcpu := ^uint(0)
+		Expect(FoundIndex(List{{1, 1}, {3, maxcpu}}.IndexOf(math.MaxInt + 2))).To(
+			Equal(math.MaxInt))
+		Expect(List{{0, maxcpu}}.IndexOf(math.MaxInt + 1)).Error().To(BeFalse())
+		Expect(List{{0, 0}, {2, maxcpu}}.IndexOf(math.MaxInt + 2)).Error().To(BeFalse())
+		Expect(List{{0, math.MaxInt}, {maxcpu, maxcpu}}.IndexOf(maxcpu)).Error().To(BeFalse())
+		Expect(FoundIndex(List{{0, math.MaxInt - 1}, {maxcpu, maxcpu}}.IndexOf(maxcpu))).To(
+			Equal(math.MaxInt))
+	})
+
+	When("iterating", func() {
+
+		It("iterates over all CPUs", func() {
+			Expect(slices.Collect(List{}.All())).To(BeEmpty())
+			Expect(slices.Collect(List{{1, 3}, {5, 5}, {7, 8}}.All())).To(
+				Equal([]uint{1, 2, 3, 5, 7, 8}))
+			maxcpu := ^uint(0)
+			Expect(slices.Collect(List{{maxcpu - 1, maxcpu}}.All())).To(
+				Equal([]uint{maxcpu - 1, maxcpu}))
+		})
+
+		It("iterates over all ranges", func() {
+			var ranges List
+			for from, to := range (List{{1, 3}, {5, 5}}).Ranges() {
+				ranges = append(ranges, [2]uint{from, to})
+			}
+			Expect(ranges).To(Equal(List{{1, 3}, {5, 5}}))
+		})
+
+		It("stops iterating early", func() {
+			l := List{{1, 3}, {5, 5}}
+			for cpu := range l.All() {
+				Expect(cpu).To(Equal(uint(1)))
+				break
+			}
+			for from := range l.Ranges() {
+				Expect(from).To(Equal(uint(1)))
+				break
+			}
+		})
+
+		DescribeTable("collecting CPUs into lists",
+			func(cpus []uint, expected List) {
+				Expect(CollectList(slices.Values(cpus))).To(Equal(expected))
+			},
+			Entry(nil, nil, List{}),
+			Entry(nil, []uint{42}, List{{42, 42}}),
+			Entry(nil, []uint{1, 2, 3, 5, 7, 8}, List{{1, 3}, {5, 5}, {7, 8}}),
+			Entry(nil, []uint{1, 1, 2, 2}, List{{1, 2}}),
+			Entry(nil, []uint{5, 1, 2, 3, 3, 7}, List{{1, 3}, {5, 5}, {7, 7}}),
+		)
+
+		It("round-trips", func() {
+			l := Successful(NewList([]byte("0-3,5,8-255,300")))
+			Expect(CollectList(l.All())).To(Equal(l))
+		})
+
+	})
+
 	DescribeTable("removing CPUs",
 		func(l string, cpu int, remainers string) {
 			c, rem := Successful(NewList([]byte(l))).Remove()
@@ -0,0 +1,242 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"fmt"
+	"iter"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// MaxCPUs is the number of CPUs a [Mask] can hold, corresponding with the
+// maximum NR_CPUS the Linux kernel can be configured for.
+const MaxCPUs = 8192
+
+// Mask is a fixed-size CPU bit string for CPUs 0 to [MaxCPUs]-1, with the same
+// bit layout as [Set]. In contrast to Set and [List], Masks are comparable, so
+// they can be directly compared using “==” and used as map keys. And as Masks
+// don't need any heap allocations, they are suitable for hot paths, such as
+// when repeatedly querying task affinities using [AffinityMask].
+//
+// Masks are rather large at 1kiB, so the Mask methods modifying, iterating
+// over, or combining Masks work on pointers to Masks in order to avoid copying.
+// Only String, the conversions, and the queries work on Mask values, so that
+// they can be called directly on Masks returned from functions, such as
+// [AffinityMask]. As a consequence, only *Mask implements the [CPUs]
+// interface. Methods adding CPUs panic when passed CPU numbers outside the
+// Mask's capacity.
+type Mask [MaxCPUs / 64]uint64
+
+// set returns a Set view onto this Mask, sharing the Mask's array.
+func (m *Mask) set() Set { return Set(m[:]) }
+
+// mustFit panics if the specified CPU doesn't fit into a Mask.
+func mustFit(cpu uint) {
+	if cpu >= MaxCPUs {
+		panic(fmt.Sprintf("CPU %d beyond Mask capacity of %d CPUs", cpu, MaxCPUs))
+	}
+}
+
+// Mask returns the Mask corresponding with this Set. If this Set contains CPUs
+// beyond the capacity of a Mask, then [ErrBeyondCapacity] is returned instead.
+func (s Set) Mask() (Mask, error) {
+	var m Mask
+	s = s.Compact()
+	if len(s) > len(m) {
+		cpu, _ := s.Max()
+		return m, fmt.Errorf("%w: Mask holds only %d CPUs, found CPU %d",
+			ErrBeyondCapacity, MaxCPUs, cpu)
+	}
+	copy(m[:], s)
+	return m, nil
+}
+
+// Mask returns the Mask corresponding with this List. If this List contains
+// CPUs beyond the capacity of a Mask, then [ErrBeyondCapacity] is returned
+// instead.
+func (l List) Mask() (Mask, error) {
+	var m Mask
+	for _, r := range l {
+		if r[1] >= MaxCPUs {
+			return m, fmt.Errorf("%w: Mask holds only %d CPUs, found CPU %d",
+				ErrBeyondCapacity, MaxCPUs, r[1])
+		}
+		m.set().fillRange(r[0], r[1])
+	}
+	return m, nil
+}
+
+// AffinityMask returns the affinity Mask of the task/process with the passed
+// TID. Otherwise, it returns an error. If tid is zero, then the affinity Mask of
+// the calling thread is returned (make sure to have the OS-level thread locked
+// to the calling go routine in this case).
+//
+// In contrast to [Affinity], AffinityMask doesn't allocate.
+func AffinityMask(tid int) (Mask, error) {
+	var m Mask
+	_, _, e := unix.RawSyscall(unix.SYS_SCHED_GETAFFINITY,
+		uintptr(tid), unsafe.Sizeof(m), uintptr(unsafe.Pointer(&m[0])))
+	if e != 0 {
+		return m, e
+	}
+	return m, nil
+}
+
+// PinTask pins the process/task identified by tid to the CPUs specified in this
+// Mask. If it fails, it returns an error instead.
+func (m *Mask) PinTask(tid int) error {
+	return SetAffinity(tid, m.set().Compact())
+}
+
+// Set returns the Set corresponding with this Mask, without any trailing zero
+// words.
+func (m Mask) Set() Set {
+	compacted := m.set().Compact()
+	s := make(Set, len(compacted))
+	copy(s, compacted)
+	return s
+}
+
+// List returns the list of CPU ranges corresponding with this Mask.
+func (m Mask) List() List { return m.set().List() }
+
+// String returns the CPUs in this Mask in textual list format.
+func (m Mask) String() string { return m.set().String() }
+
+// IsSet reports whether cpu is in this Mask.
+func (m Mask) IsSet(cpu uint) bool { return m.set().IsSet(cpu) }
+
+// Contains reports whether cpu is in this Mask; it is the same as
+// [Mask.IsSet].
+func (m Mask) Contains(cpu uint) bool { return m.set().IsSet(cpu) }
+
+// IsEmpty returns true if this Mask doesn't contain any CPUs, otherwise false.
+func (m Mask) IsEmpty() bool { return m == Mask{} }
+
+// Count returns the number of CPUs in this Mask.
+func (m Mask) Count() int { return m.set().Count() }
+
+// Min returns the lowest CPU in this Mask and true, otherwise false if the
+// Mask is empty.
+func (m Mask) Min() (cpu uint, ok bool) { return m.set().Min() }
+
+// Max returns the highest CPU in this Mask and true, otherwise false if the
+// Mask is empty.
+func (m Mask) Max() (cpu uint, ok bool) { return m.set().Max() }
+
+// Nth returns the CPU with the 0-based logical index n in this Mask and true.
+// If there are only n or less CPUs in this Mask, Nth returns false.
+func (m Mask) Nth(n int) (cpu uint, ok bool) { return m.set().Nth(n) }
+
+// IndexOf returns the 0-based logical index of the specified CPU within this
+// Mask and true, otherwise false if the CPU is not in this Mask.
+func (m Mask) IndexOf(cpu uint) (n int, ok bool) { return m.set().IndexOf(cpu) }
+
+// All returns an iterator over the individual CPUs in this Mask, in ascending
+// order.
+func (m *Mask) All() iter.Seq[uint] { return m.set().All() }
+
+// Ranges returns an iterator over the CPU ranges in this Mask, in ascending
+// order, yielding the first and last CPU of each range.
+func (m *Mask) Ranges() iter.Seq2[uint, uint] { return m.set().Ranges() }
+
+// Add adds the specified CPU to this Mask.
+func (m *Mask) Add(cpu uint) {
+	mustFit(cpu)
+	m[setBitIndex(cpu)] |= setBitMask(cpu)
+}
+
+// Fill adds the CPU(s) from the specified range to this Mask.
+func (m *Mask) Fill(from, to uint) {
+	if from > to {
+		panic(fmt.Sprintf("invalid range %d-%d", from, to))
+	}
+	mustFit(to)
+	m.set().fillRange(from, to)
+}
+
+// Remove removes the specified CPU from this Mask.
+func (m *Mask) Remove(cpu uint) {
+	s := m.set()
+	s.Remove(cpu)
+}
+
+// RemoveRange removes the CPU(s) from the specified range from this Mask.
+func (m *Mask) RemoveRange(from, to uint) {
+	s := m.set()
+	s.RemoveRange(from, to)
+}
+
+// Toggle adds the specified CPU to this Mask if it isn't in the Mask, otherwise
+// it removes the CPU from this Mask.
+func (m *Mask) Toggle(cpu uint) {
+	mustFit(cpu)
+	m[setBitIndex(cpu)] ^= setBitMask(cpu)
+}
+
+// Clear removes all CPUs from this Mask.
+func (m *Mask) Clear() { *m = Mask{} }
+
+// IsOverlapping returns true if this Mask and another overlap, otherwise false.
+func (m *Mask) IsOverlapping(another *Mask) bool {
+	return m.set().IsOverlapping(another.set())
+}
+
+// Overlap returns the overlap of this Mask with another.
+func (m *Mask) Overlap(another *Mask) (overlap Mask) {
+	for idx := range m {
+		overlap[idx] = m[idx] & another[idx]
+	}
+	return
+}
+
+// Union returns the union of this Mask with another.
+func (m *Mask) Union(another *Mask) (union Mask) {
+	for idx := range m {
+		union[idx] = m[idx] | another[idx]
+	}
+	return
+}
+
+// Difference returns the CPUs from this Mask that are not in another Mask.
+func (m *Mask) Difference(another *Mask) (diff Mask) {
+	for idx := range m {
+		diff[idx] = m[idx] &^ another[idx]
+	}
+	return
+}
+
+// SymmetricDifference returns the CPUs that are either in this Mask or in
+// another Mask, but not in both.
+func (m *Mask) SymmetricDifference(another *Mask) (symdiff Mask) {
+	for idx := range m {
+		symdiff[idx] = m[idx] ^ another[idx]
+	}
+	return
+}
+
+// Complement returns all CPUs in the range from 0 to cpus-1 that are not in
+// this Mask. cpus is capped at [MaxCPUs].
+func (m *Mask) Complement(cpus uint) (complement Mask) {
+	for idx := range m {
+		complement[idx] = ^m[idx]
+	}
+	if cpus < MaxCPUs {
+		complement.RemoveRange(cpus, MaxCPUs-1)
+	}
+	return
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+func mustMask(text string) *Mask {
+	GinkgoHelper()
+	m := Successful(Successful(NewList([]byte(text))).Mask())
+	return &m
+}
+
+var _ = Describe("cpu masks", func() {
+
+	It("has the kernel's maximum NR_CPUS capacity", func() {
+		Expect(len(Mask{}) * 64).To(Equal(MaxCPUs))
+	})
+
+	When("converting", func() {
+
+		DescribeTable("round-tripping lists and sets",
+			func(text string) {
+				l := Successful(NewList([]byte(text)))
+				m := Successful(l.Mask())
+				Expect(m.List()).To(Equal(l))
+				Expect(m.String()).To(Equal(text))
+				Expect(m.Set().Equal(l.Set())).To(BeTrue())
+				Expect(m.Set()).To(Equal(l.Set().Compact()))
+				Expect(l.Set().Mask()).To(Equal(m))
+			},
+			Entry(nil, ""),
+			Entry(nil, "0"),
+			Entry(nil, "1-3,5,63-64"),
+			Entry(nil, "0-8191"),
+			Entry(nil, "8191"),
+		)
+
+		It("rejects CPUs beyond the capacity", func() {
+			Expect(List{{0, MaxCPUs}}.Mask()).Error().To(MatchError(ErrBeyondCapacity))
+			var s Set
+			s.Add(MaxCPUs)
+			Expect(s.Mask()).Error().To(MatchError(ErrBeyondCapacity))
+			s.Remove(MaxCPUs)
+			s.Add(MaxCPUs - 1)
+			Expect(s.Mask()).Error().NotTo(HaveOccurred())
+		})
+
+	})
+
+	It("is comparable and can be used as map key", func() {
+		m1 := mustMask("1-3,666")
+		m2 := mustMask("1-3,666")
+		Expect(*m1 == *m2).To(BeTrue())
+		m2.Add(667)
+		Expect(*m1 == *m2).To(BeFalse())
+
+		masks := map[Mask]struct{}{}
+		masks[*m1] = struct{}{}
+		masks[*mustMask("1-3,666")] = struct{}{}
+		masks[*m2] = struct{}{}
+		Expect(masks).To(HaveLen(2))
+	})
+
+	It("queries CPUs", func() {
+		m := mustMask("1-3,5,200-300")
+		Expect(m.IsEmpty()).To(BeFalse())
+		Expect((&Mask{}).IsEmpty()).To(BeTrue())
+		Expect(m.Count()).To(Equal(105))
+		Expect(Found(m.Min())).To(Equal(uint(1)))
+		Expect(Found(m.Max())).To(Equal(uint(300)))
+		Expect(Found(m.Nth(3))).To(Equal(uint(5)))
+		Expect(FoundIndex(m.IndexOf(200))).To(Equal(4))
+		Expect(m.IndexOf(4)).Error().To(BeFalse())
+		Expect(m.IsSet(2)).To(BeTrue())
+		Expect(m.Contains(4)).To(BeFalse())
+		Expect(m.Contains(MaxCPUs)).To(BeFalse())
+		Expect(slices.Collect(mustMask("1-3,64").All())).To(Equal([]uint{1, 2, 3, 64}))
+		var ranges List
+		for from, to := range m.Ranges() {
+			ranges = append(ranges, [2]uint{from, to})
+		}
+		Expect(ranges).To(Equal(m.List()))
+	})
+
+	It("mutates", func() {
+		var m Mask
+		m.Add(1)
+		m.Fill(60, 70)
+		m.Toggle(8191)
+		Expect(m.String()).To(Equal("1,60-70,8191"))
+		m.Remove(1)
+		m.RemoveRange(62, 69)
+		m.Toggle(8191)
+		Expect(m.String()).To(Equal("60-61,70"))
+		m.Clear()
+		Expect(m.IsEmpty()).To(BeTrue())
+
+		Expect(func() { m.Add(MaxCPUs) }).To(Panic())
+		Expect(func() { m.Toggle(MaxCPUs) }).To(Panic())
+		Expect(func() { m.Fill(0, MaxCPUs) }).To(Panic())
+		Expect(func() { m.Fill(1, 0) }).To(Panic())
+		Expect(func() { m.Remove(MaxCPUs) }).NotTo(Panic())
+		Expect(func() { m.RemoveRange(0, MaxCPUs) }).NotTo(Panic())
+	})
+
+	DescribeTable("set algebra",
+		func(l1, l2 string, overlap, union, diff, symdiff string) {
+			m1, m2 := mustMask(l1), mustMask(l2)
+			Expect(m1.IsOverlapping(m2)).To(Equal(overlap != ""))
+			Expect(m1.Overlap(m2)).To(Equal(*mustMask(overlap)))
+			Expect(m1.Union(m2)).To(Equal(*mustMask(union)))
+			Expect(m1.Difference(m2)).To(Equal(*mustMask(diff)))
+			Expect(m1.SymmetricDifference(m2)).To(Equal(*mustMask(symdiff)))
+		},
+		Entry(nil, "", "", "", "", "", ""),
+		Entry(nil, "1-5", "3-9", "3-5", "1-9", "1-2", "1-2,6-9"),
+		Entry(nil, "1-3", "64-66", "", "1-3,64-66", "1-3", "1-3,64-66"),
+	)
+
+	DescribeTable("complements",
+		func(text string, cpus int, expected string) {
+			Expect(mustMask(text).Complement(uint(cpus))).To(Equal(*mustMask(expected)))
+		},
+		Entry(nil, "", 0, ""),
+		Entry(nil, "1-3", 8, "0,4-7"),
+		Entry(nil, "1-3", 70, "0,4-69"),
+		Entry(nil, "1-8190", MaxCPUs, "0,8191"),
+		Entry(nil, "1-8190", MaxCPUs+1, "0,8191"),
+	)
+
+	When("getting and setting affinities", func() {
+
+		It("gets this process's CPU affinity mask without allocations", func() {
+			m := Successful(AffinityMask(os.Getpid()))
+			Expect(m.List()).To(Equal(Successful(Affinity(os.Getpid())).List()))
+			Expect(testing.AllocsPerRun(10, func() {
+				_, _ = AffinityMask(0)
+			})).To(BeZero())
+		})
+
+		It("changes this process's CPU affinity", func() {
+			runtime.LockOSThread() // don't unlock, throw away the tainted task
+
+			affs := Successful(AffinityMask(0))
+			cpu := Found(affs.Min())
+			var oneonly Mask
+			oneonly.Add(cpu)
+			Expect(oneonly.PinTask(0)).To(Succeed())
+			Expect(AffinityMask(0)).To(Equal(oneonly))
+			Expect(affs.PinTask(0)).To(Succeed())
+		})
+
+		It("formats and queries returned mask values", func() {
+			Expect(Successful(AffinityMask(0)).String()).NotTo(BeEmpty())
+			m := Successful(AffinityMask(0))
+			Expect(fmt.Sprint(m)).To(Equal(m.String()))
+			Expect(Successful(AffinityMask(0)).Count()).To(Equal(m.Count()))
+		})
+
+		It("reports errors", func() {
+			Expect(AffinityMask(-1)).Error().To(HaveOccurred())
+		})
+
+	})
+
+})
@@ -0,0 +1,217 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// sysNodeDir is the sysfs directory with the NUMA node information, relative to
+// the sysfs root.
+const sysNodeDir = "devices/system/node"
+
+// NUMA describes the NUMA nodes of a system. The node sets use the same list
+// format as CPU lists, but contain node IDs instead of CPU numbers.
+type NUMA struct {
+	Possible  List // possible nodes.
+	Online    List // online nodes.
+	HasCPU    List // nodes with CPUs.
+	HasMemory List // nodes with memory.
+	// Nodes are the online nodes, in ascending order of their IDs.
+	Nodes []NUMANode
+}
+
+// NUMANode describes a single online NUMA node.
+type NUMANode struct {
+	ID   uint // node ID.
+	CPUs List // CPUs local to this node; empty for memory-only nodes.
+	// Distances are the relative distances from this node to all online nodes,
+	// in the same order as [NUMA.Nodes]. The distance of a node to itself is
+	// normally 10.
+	Distances []int
+	MemTotal  uint64 // total memory in bytes.
+	MemFree   uint64 // free memory in bytes.
+}
+
+// NUMA returns the NUMA nodes of the system. Otherwise, it returns an error.
+func (fs Sysfs) NUMA() (*NUMA, error) {
+	var numa NUMA
+	var err error
+	for _, nodes := range []struct {
+		l    *List
+		name string
+	}{
+		{&numa.Possible, "possible"},
+		{&numa.Online, "online"},
+		{&numa.HasCPU, "has_cpu"},
+		{&numa.HasMemory, "has_memory"},
+	} {
+		if *nodes.l, err = fs.readList(sysNodeDir, nodes.name); err != nil {
+			return nil, err
+		}
+	}
+	numa.Nodes = make([]NUMANode, 0, numa.Online.Count())
+	for id := range numa.Online.All() {
+		node, err := fs.NUMANode(id)
+		if err != nil {
+			return nil, err
+		}
+		if len(node.Distances) != numa.Online.Count() {
+			return nil, fmt.Errorf("node %d has %d distances, expected %d",
+				id, len(node.Distances), numa.Online.Count())
+		}
+		numa.Nodes = append(numa.Nodes, *node)
+	}
+	return &numa, nil
+}
+
+// NUMANode returns the information about the specified online NUMA node.
+// Otherwise, it returns an error.
+func (fs Sysfs) NUMANode(id uint) (*NUMANode, error) {
+	dir := filepath.Join(sysNodeDir, "node"+strconv.FormatUint(uint64(id), 10))
+	node := NUMANode{ID: id}
+	var err error
+	if node.CPUs, err = fs.readList(dir, "cpulist"); err != nil {
+		return nil, err
+	}
+	distances, err := fs.readString(dir, "distance")
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range strings.Fields(distances) {
+		distance, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid distance in %q, %w",
+				fs.path(dir, "distance"), err)
+		}
+		node.Distances = append(node.Distances, distance)
+	}
+	if node.MemTotal, node.MemFree, err = fs.readNodeMeminfo(dir); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// readNodeMeminfo returns the total and free memory in bytes from the meminfo
+// file in the specified node directory. The meminfo lines are in the format
+// “Node 0 MemTotal:  4816632 kB”.
+func (fs Sysfs) readNodeMeminfo(dir string) (total, free uint64, err error) {
+	path := fs.path(dir, "meminfo")
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	lines := bufio.NewScanner(bytes.NewReader(text))
+	for lines.Scan() {
+		fields := strings.Fields(lines.Text())
+		if len(fields) < 4 || fields[0] != "Node" {
+			continue
+		}
+		var value *uint64
+		switch fields[2] {
+		case "MemTotal:":
+			value = &total
+		case "MemFree:":
+			value = &free
+		default:
+			continue
+		}
+		amount, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid %s in %q, %w",
+				strings.TrimSuffix(fields[2], ":"), path, err)
+		}
+		if len(fields) > 4 && fields[4] == "kB" {
+			amount *= 1024
+		}
+		*value = amount
+	}
+	return total, free, nil
+}
+
+// Node returns the specified online node, or false if there is no such node.
+func (n *NUMA) Node(id uint) (*NUMANode, bool) {
+	idx, ok := n.index(id)
+	if !ok {
+		return nil, false
+	}
+	return &n.Nodes[idx], true
+}
+
+// NodeOf returns the ID of the node the specified CPU is local to, or false if
+// the CPU doesn't belong to any online node.
+func (n *NUMA) NodeOf(cpu uint) (id uint, ok bool) {
+	for idx := range n.Nodes {
+		if n.Nodes[idx].CPUs.Contains(cpu) {
+			return n.Nodes[idx].ID, true
+		}
+	}
+	return 0, false
+}
+
+// CPUsOfNode returns the CPUs local to the specified node. If there is no such
+// online node, the List is empty.
+func (n *NUMA) CPUsOfNode(id uint) List {
+	node, ok := n.Node(id)
+	if !ok {
+		return List{}
+	}
+	return node.CPUs
+}
+
+// Distance returns the relative distance between the specified nodes, or false
+// if either node isn't online.
+func (n *NUMA) Distance(from, to uint) (distance int, ok bool) {
+	fromIdx, ok := n.index(from)
+	if !ok {
+		return 0, false
+	}
+	toIdx, ok := n.index(to)
+	if !ok || toIdx >= len(n.Nodes[fromIdx].Distances) {
+		return 0, false
+	}
+	return n.Nodes[fromIdx].Distances[toIdx], true
+}
+
+// Distances returns the distance matrix of the online nodes, with the rows and
+// columns in the same order as [NUMA.Nodes].
+func (n *NUMA) Distances() [][]int {
+	matrix := make([][]int, 0, len(n.Nodes))
+	for idx := range n.Nodes {
+		matrix = append(matrix, slices.Clone(n.Nodes[idx].Distances))
+	}
+	return matrix
+}
+
+// index returns the index into Nodes of the specified node, or false if there
+// is no such online node.
+func (n *NUMA) index(id uint) (int, bool) {
+	return slices.BinarySearchFunc(n.Nodes, id, func(node NUMANode, id uint) int {
+		switch {
+		case node.ID < id:
+			return -1
+		case node.ID > id:
+			return 1
+		}
+		return 0
+	})
+}
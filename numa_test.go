@@ -0,0 +1,147 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+// numaFixture is a system with two nodes with CPUs and a memory-only node, such
+// as CXL-attached memory, plus a further possible but offline node.
+var numaFixture = sysfsFiles{
+	"devices/system/node/possible":   "0-3",
+	"devices/system/node/online":     "0-2",
+	"devices/system/node/has_cpu":    "0-1",
+	"devices/system/node/has_memory": "0-2",
+
+	"devices/system/node/node0/cpulist":  "0-3,8-11",
+	"devices/system/node/node0/distance": "10 21 40",
+	"devices/system/node/node0/meminfo": `Node 0 MemTotal:       16384 kB
+Node 0 MemFree:         8192 kB
+Node 0 MemUsed:         8192 kB`,
+
+	"devices/system/node/node1/cpulist":  "4-7,12-15",
+	"devices/system/node/node1/distance": "21 10 40",
+	"devices/system/node/node1/meminfo": `Node 1 MemTotal:       32768 kB
+Node 1 MemFree:         1024 kB`,
+
+	"devices/system/node/node2/cpulist":  "",
+	"devices/system/node/node2/distance": "40 40 10",
+	"devices/system/node/node2/meminfo": `Node 2 MemTotal:       65536 kB
+Node 2 MemFree:        65536 kB`,
+}
+
+func distance(d int, ok bool) int {
+	GinkgoHelper()
+	Expect(ok).To(BeTrue(), "expected a distance")
+	return d
+}
+
+var _ = Describe("NUMA nodes", func() {
+
+	It("discovers the nodes", func() {
+		numa := Successful(fakeSysfs(numaFixture).NUMA())
+		Expect(numa.Possible).To(Equal(List{{0, 3}}))
+		Expect(numa.Online).To(Equal(List{{0, 2}}))
+		Expect(numa.HasCPU).To(Equal(List{{0, 1}}))
+		Expect(numa.HasMemory).To(Equal(List{{0, 2}}))
+		Expect(numa.Nodes).To(Equal([]NUMANode{
+			{ID: 0, CPUs: List{{0, 3}, {8, 11}}, Distances: []int{10, 21, 40},
+				MemTotal: 16 << 20, MemFree: 8 << 20},
+			{ID: 1, CPUs: List{{4, 7}, {12, 15}}, Distances: []int{21, 10, 40},
+				MemTotal: 32 << 20, MemFree: 1 << 20},
+			{ID: 2, CPUs: List{}, Distances: []int{40, 40, 10},
+				MemTotal: 64 << 20, MemFree: 64 << 20},
+		}))
+	})
+
+	It("looks up nodes, CPUs and distances", func() {
+		numa := Successful(fakeSysfs(numaFixture).NUMA())
+
+		node, ok := numa.Node(1)
+		Expect(ok).To(BeTrue())
+		Expect(node.ID).To(Equal(uint(1)))
+		Expect(numa.Node(3)).Error().To(BeFalse())
+
+		Expect(Found(numa.NodeOf(9))).To(Equal(uint(0)))
+		Expect(Found(numa.NodeOf(13))).To(Equal(uint(1)))
+		Expect(numa.NodeOf(16)).Error().To(BeFalse())
+
+		Expect(numa.CPUsOfNode(1)).To(Equal(List{{4, 7}, {12, 15}}))
+		Expect(numa.CPUsOfNode(2)).To(BeEmpty())
+		Expect(numa.CPUsOfNode(3)).To(BeEmpty())
+
+		Expect(distance(numa.Distance(0, 1))).To(Equal(21))
+		Expect(distance(numa.Distance(2, 1))).To(Equal(40))
+		Expect(numa.Distance(3, 0)).Error().To(BeFalse())
+		Expect(numa.Distance(0, 3)).Error().To(BeFalse())
+
+		matrix := numa.Distances()
+		Expect(matrix).To(Equal([][]int{{10, 21, 40}, {21, 10, 40}, {40, 40, 10}}))
+		matrix[0][0] = 666
+		Expect(distance(numa.Distance(0, 0))).To(Equal(10))
+	})
+
+	It("reports errors", func() {
+		Expect(Sysfs(GinkgoT().TempDir()).NUMA()).Error().To(MatchError(os.ErrNotExist))
+		Expect(fakeSysfs(numaFixture, sysfsFiles{
+			"devices/system/node/has_cpu": "x",
+		}).NUMA()).Error().To(MatchError(ErrExpectedNumber))
+		Expect(fakeSysfs(numaFixture, sysfsFiles{
+			"devices/system/node/node1/cpulist": "x",
+		}).NUMA()).Error().To(MatchError(ErrExpectedNumber))
+		Expect(fakeSysfs(numaFixture, sysfsFiles{
+			"devices/system/node/node1/distance": "21 x 40",
+		}).NUMA()).Error().To(MatchError(ContainSubstring("invalid distance")))
+		Expect(fakeSysfs(numaFixture, sysfsFiles{
+			"devices/system/node/node1/distance": "21 10",
+		}).NUMA()).Error().To(MatchError(ContainSubstring("has 2 distances, expected 3")))
+		Expect(fakeSysfs(numaFixture, sysfsFiles{
+			"devices/system/node/node1/meminfo": "Node 1 MemFree: x kB",
+		}).NUMA()).Error().To(MatchError(ContainSubstring("invalid MemFree")))
+
+		fs := fakeSysfs(numaFixture)
+		Expect(os.Remove(fs.path(sysNodeDir, "node2", "cpulist"))).To(Succeed())
+		Expect(fs.NUMA()).Error().To(MatchError(os.ErrNotExist))
+		fs = fakeSysfs(numaFixture)
+		Expect(os.Remove(fs.path(sysNodeDir, "node2", "distance"))).To(Succeed())
+		Expect(fs.NUMA()).Error().To(MatchError(os.ErrNotExist))
+		fs = fakeSysfs(numaFixture)
+		Expect(os.Remove(fs.path(sysNodeDir, "node2", "meminfo"))).To(Succeed())
+		Expect(fs.NUMA()).Error().To(MatchError(os.ErrNotExist))
+	})
+
+	It("discovers this system's nodes", func() {
+		if _, err := os.Stat(DefaultSysfs.path(sysNodeDir)); err != nil {
+			Skip("no NUMA support")
+		}
+		numa := Successful(DefaultSysfs.NUMA())
+		Expect(numa.Nodes).NotTo(BeEmpty())
+		online := Successful(DefaultSysfs.OnlineCPUs())
+		for cpu := range online.All() {
+			id, ok := numa.NodeOf(cpu)
+			Expect(ok).To(BeTrue(), "CPU %d without node", cpu)
+			Expect(distance(numa.Distance(id, id))).To(BeNumerically(">", 0))
+		}
+		for _, node := range numa.Nodes {
+			Expect(node.MemTotal).To(BeNumerically(">=", node.MemFree))
+		}
+	})
+
+})
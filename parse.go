@@ -0,0 +1,272 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"strconv"
+)
+
+// ParseOption configures the behavior of [ParseList].
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	extended  bool
+	maxCPU    uint
+	hasMaxCPU bool
+}
+
+// WithMaxCPU sets the highest CPU number (such as nr_cpu_ids-1) to resolve “all”
+// and “N” in CPU lists to. Additionally, CPU numbers above the maximum CPU
+// number are then rejected.
+func WithMaxCPU(cpu uint) ParseOption {
+	return func(o *parseOptions) {
+		o.maxCPU = cpu
+		o.hasMaxCPU = true
+	}
+}
+
+// ParseList returns a new CPU List in canonical form for the given text in the
+// Linux kernel's extended list format, as understood by the kernel's
+// bitmap_parselist and used, for instance, in the “isolcpus=” and “nohz_full=”
+// boot parameters. If the text is malformed then an error is returned instead.
+//
+// In addition to the format accepted by [NewList], the extended list format
+// supports:
+//   - “from-to:used/group” strided ranges where in each group of “group” CPUs,
+//     starting at “from”, only the first “used” CPUs are taken. For instance,
+//     “0-31:2/8” is equivalent to “0-1,8-9,16-17,24-25”.
+//   - “all” for the range from CPU 0 up to the maximum CPU, as set using the
+//     [WithMaxCPU] option. “all” can be strided too, such as in “all:1/2”.
+//   - “N” in place of any CPU number (but not in place of the used and group
+//     sizes) to represent the maximum CPU, as set using [WithMaxCPU].
+//
+// Without the WithMaxCPU option, “all” and “N” are reported as
+// [ErrMaxCPURequired].
+//
+// Ranges may be specified in any order and may overlap, as the resulting List
+// is always normalized.
+//
+// Malformed text is reported as a [*ParseError].
+func ParseList(text []byte, opts ...ParseOption) (List, error) {
+	options := parseOptions{extended: true}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	l := List{}
+	err := parseList(text, &options, func(from, to uint) {
+		l = append(l, [2]uint{from, to})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l.Normalize(), nil
+}
+
+// listScanner scans CPU list text, keeping track of the current position.
+type listScanner struct {
+	text []byte
+	pos  int
+}
+
+// eol returns true if the scanner has reached the end of the text.
+func (s *listScanner) eol() bool { return s.pos >= len(s.text) }
+
+// skip skips the specified byte if it is next in the text, returning true.
+// Otherwise, it returns false, leaving the scanning position unchanged.
+func (s *listScanner) skip(ch byte) bool {
+	if s.pos >= len(s.text) || s.text[s.pos] != ch {
+		return false
+	}
+	s.pos++
+	return true
+}
+
+// skipText skips the specified text if it is next, returning true. Otherwise,
+// it returns false, leaving the scanning position unchanged.
+func (s *listScanner) skipText(text string) bool {
+	if len(s.text)-s.pos < len(text) || string(s.text[s.pos:s.pos+len(text)]) != text {
+		return false
+	}
+	s.pos += len(text)
+	return true
+}
+
+// number returns the unsigned decimal number at the current position.
+// Otherwise, if there is no number or the number overflows, it returns a
+// ParseError.
+func (s *listScanner) number() (uint, error) {
+	start := s.pos
+	var num uint
+	for s.pos < len(s.text) && isDigit(s.text[s.pos]) {
+		digit := uint(s.text[s.pos] - '0')
+		if num > (^uint(0)-digit)/10 {
+			return 0, newParseError(s.text, start, ErrCPUOutOfRange)
+		}
+		num = num*10 + digit
+		s.pos++
+	}
+	if s.pos == start {
+		return 0, newParseError(s.text, start, ErrExpectedNumber)
+	}
+	return num, nil
+}
+
+// parseList parses the text in list format, calling add for each CPU range
+// found in the text in the order of appearance. Unless enabled in the options,
+// the extended list format is rejected.
+func parseList(text []byte, options *parseOptions, add func(from, to uint)) error {
+	scan := listScanner{text: text}
+	if scan.eol() {
+		return nil
+	}
+	for {
+		if err := parseRegion(&scan, options, add); err != nil {
+			return err
+		}
+		if scan.eol() {
+			return nil
+		}
+		if !scan.skip(',') {
+			return newParseError(text, scan.pos, ErrExpectedSeparator)
+		}
+	}
+}
+
+// parseRegion parses a single (and optionally strided) CPU range, a single CPU
+// number, or “all”.
+func parseRegion(scan *listScanner, options *parseOptions, add func(from, to uint)) error {
+	var from, to uint
+	toPos := scan.pos
+	if options.extended && scan.skipText("all") {
+		if !options.hasMaxCPU {
+			return newParseError(scan.text, toPos, ErrMaxCPURequired)
+		}
+		from, to = 0, options.maxCPU
+	} else {
+		var err error
+		if from, err = parseCPU(scan, options); err != nil {
+			return err
+		}
+		to = from
+		if scan.skip('-') {
+			toPos = scan.pos
+			if to, err = parseCPU(scan, options); err != nil {
+				return err
+			}
+		}
+	}
+	if from > to {
+		return newParseError(scan.text, toPos, ErrInvertedRange)
+	}
+	if options.hasMaxCPU && to > options.maxCPU {
+		return newParseError(scan.text, toPos, ErrCPUOutOfRange)
+	}
+	if !options.extended || !scan.skip(':') {
+		add(from, to)
+		return nil
+	}
+	// It's a strided range, so in each group of CPUs only the first “used”
+	// CPUs are taken.
+	usedPos := scan.pos
+	used, err := scan.number()
+	if err != nil {
+		return err
+	}
+	if !scan.skip('/') {
+		return newParseError(scan.text, scan.pos, ErrExpectedSeparator)
+	}
+	group, err := scan.number()
+	if err != nil {
+		return err
+	}
+	if group == 0 || used > group {
+		return newParseError(scan.text, usedPos, ErrInvalidGroup)
+	}
+	if used == 0 {
+		return nil
+	}
+	for start := from; start <= to; start += group {
+		add(start, start+min(used-1, to-start))
+		if start+group < start {
+			break // don't wrap around
+		}
+	}
+	return nil
+}
+
+// parseCPU parses either a CPU number or, in extended list format, “N” for the
+// maximum CPU.
+func parseCPU(scan *listScanner, options *parseOptions) (uint, error) {
+	if options.extended && scan.skip('N') {
+		if !options.hasMaxCPU {
+			return 0, newParseError(scan.text, scan.pos-1, ErrMaxCPURequired)
+		}
+		return options.maxCPU, nil
+	}
+	return scan.number()
+}
+
+// CompactString returns the CPU list in the kernel's extended textual format,
+// similar to [List.String], but using strided “from-to:used/group” notation
+// wherever this results in shorter text. For instance, “0-1,8-9,16-17,24-25”
+// becomes “0-25:2/8”. The List must be in canonical form.
+//
+// See also [ParseList].
+func (l List) CompactString() string {
+	var b []byte
+	for idx := 0; idx < len(l); {
+		if idx > 0 {
+			b = append(b, ',')
+		}
+		// Find the longest run of equally sized ranges with the same distance
+		// between them, starting at the current range. Only emit it in strided
+		// notation if the result is actually shorter than listing all ranges
+		// of the run.
+		if n := strideRun(l[idx:]); n >= 2 {
+			from, to := l[idx][0], l[idx+n-1][1]
+			used, group := l[idx][1]-l[idx][0]+1, l[idx+1][0]-l[idx][0]
+			strided := strconv.AppendUint(nil, uint64(from), 10)
+			strided = append(strided, '-')
+			strided = strconv.AppendUint(strided, uint64(to), 10)
+			strided = append(strided, ':')
+			strided = strconv.AppendUint(strided, uint64(used), 10)
+			strided = append(strided, '/')
+			strided = strconv.AppendUint(strided, uint64(group), 10)
+			if len(strided) < len(l[idx:idx+n].String()) {
+				b = append(b, strided...)
+				idx += n
+				continue
+			}
+		}
+		b = append(b, l[idx:idx+1].String()...)
+		idx++
+	}
+	return string(b)
+}
+
+// strideRun returns the number of ranges at the beginning of the specified list
+// that all have the same size and the same distance between them.
+func strideRun(l List) int {
+	if len(l) < 2 {
+		return len(l)
+	}
+	size := l[0][1] - l[0][0]
+	group := l[1][0] - l[0][0]
+	n := 1
+	for n < len(l) && l[n][1]-l[n][0] == size && l[n][0]-l[n-1][0] == group {
+		n++
+	}
+	return n
+}
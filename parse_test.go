@@ -0,0 +1,114 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/table"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("extended cpu lists", func() {
+
+	DescribeTable("parsing",
+		func(text string, maxcpu int, expected string) {
+			var opts []ParseOption
+			if maxcpu >= 0 {
+				opts = append(opts, WithMaxCPU(uint(maxcpu)))
+			}
+			Expect(Successful(ParseList([]byte(text), opts...)).String()).To(Equal(expected))
+		},
+		Entry(nil, "", -1, ""),
+		Entry(nil, "42", -1, "42"),
+		Entry(nil, "1,2,42-666", -1, "1-2,42-666"),
+		Entry(nil, "5,1-3,2-4", -1, "1-5"),
+		Entry(nil, "0-31:2/8", -1, "0-1,8-9,16-17,24-25"),
+		Entry(nil, "0-30:3/8", -1, "0-2,8-10,16-18,24-26"),
+		Entry(nil, "0-25:3/8", -1, "0-2,8-10,16-18,24-25"),
+		Entry(nil, "4:1/2", -1, "4"),
+		Entry(nil, "0-7:0/2", -1, ""),
+		Entry(nil, "0-7:2/2", -1, "0-7"),
+		Entry(nil, "all", 7, "0-7"),
+		Entry(nil, "all:1/2", 7, "0,2,4,6"),
+		Entry(nil, "N", 63, "63"),
+		Entry(nil, "2-N", 63, "2-63"),
+		Entry(nil, "1,3-N:1/4", 15, "1,3,7,11,15"),
+	)
+
+	DescribeTable("parsing errors",
+		func(text string, maxcpu int, kind error, offset int) {
+			var opts []ParseOption
+			if maxcpu >= 0 {
+				opts = append(opts, WithMaxCPU(uint(maxcpu)))
+			}
+			_, err := ParseList([]byte(text), opts...)
+			Expect(err).To(MatchError(kind))
+			Expect(err).To(BeAssignableToTypeOf(&ParseError{}))
+			Expect(err.(*ParseError).Offset).To(Equal(offset))
+		},
+		Entry(nil, "abc", -1, ErrExpectedNumber, 0),
+		Entry(nil, "1,", -1, ErrExpectedNumber, 2),
+		Entry(nil, "1,,2", -1, ErrExpectedNumber, 2),
+		Entry(nil, "0abc", -1, ErrExpectedSeparator, 1),
+		Entry(nil, "42-", -1, ErrExpectedNumber, 3),
+		Entry(nil, "666-42", -1, ErrInvertedRange, 4),
+		Entry(nil, "99999999999999999999999", -1, ErrCPUOutOfRange, 0),
+		Entry(nil, "all", -1, ErrMaxCPURequired, 0),
+		Entry(nil, "1-N", -1, ErrMaxCPURequired, 2),
+		Entry(nil, "N", -1, ErrMaxCPURequired, 0),
+		Entry(nil, "0-64", 63, ErrCPUOutOfRange, 2),
+		Entry(nil, "0-7:", -1, ErrExpectedNumber, 4),
+		Entry(nil, "0-7:1", -1, ErrExpectedSeparator, 5),
+		Entry(nil, "0-7:1/", -1, ErrExpectedNumber, 6),
+		Entry(nil, "0-7:1/0", -1, ErrInvalidGroup, 4),
+		Entry(nil, "0-7:3/2", -1, ErrInvalidGroup, 4),
+	)
+
+	It("doesn't overflow strided ranges at the top end", func() {
+		top := ^uint(0)
+		Expect(ParseList(fmt.Appendf(nil, "%d-%d:3/3", top-1, top))).To(
+			Equal(List{{top - 1, top}}))
+		Expect(ParseList(fmt.Appendf(nil, "%d-%d:1/2", top-2, top))).To(
+			Equal(List{{top - 2, top - 2}, {top, top}}))
+		Expect(ParseList(fmt.Appendf(nil, "%d:2/4", top))).To(
+			Equal(List{{top, top}}))
+	})
+
+	It("tells when a maximum CPU is required", func() {
+		Expect(ParseList([]byte("0-N"))).Error().To(MatchError(
+			`“all” and “N” require a maximum CPU at offset 2, found "N"`))
+	})
+
+	DescribeTable("generating compact textual representations",
+		func(text string, expected string) {
+			l := Successful(ParseList([]byte(text)))
+			compact := l.CompactString()
+			Expect(compact).To(Equal(expected))
+			Expect(ParseList([]byte(compact))).To(Equal(l))
+		},
+		Entry(nil, "", ""),
+		Entry(nil, "42", "42"),
+		Entry(nil, "1-3,5", "1-3,5"),
+		Entry(nil, "1,3", "1,3"),
+		Entry(nil, "1,3,5,7,9", "1-9:1/2"),
+		Entry(nil, "0-31:2/8", "0-25:2/8"),
+		Entry(nil, "0,100-200,300-310:1/2,400", "0,100-200,300-310:1/2,400"),
+		Entry(nil, "0-1,8-9", "0-1,8-9"),
+	)
+
+})
@@ -15,8 +15,11 @@
 package cpus
 
 import (
+	"encoding/binary"
 	"fmt"
+	"iter"
 	"math/bits"
+	"slices"
 	"sync/atomic"
 	"syscall"
 	"unsafe"
@@ -29,7 +32,14 @@ import (
 // (MSB) for CPU 63. If present, the second element represents CPU numbers
 // 64–127, with bit 0 (LSB) corresponding with CPU 64 and bit 63 (MSB)
 // corresponding with CPU 127, and so on. A zero length Set represents an empty
-// Set, as do non-zero length Sets with only zero value elements.
+// Set, as do non-zero length Sets with only zero value elements. Thus, use
+// [Set.Equal] to compare Sets and [Set.Key] for map keys, instead of comparing
+// the Set elements.
+//
+// Sets can be modified in place, growing only when necessary, using [Set.Add],
+// [Set.Remove], [Set.RemoveRange], and [Set.Toggle]. [Set.Clear] and [Set.Fill]
+// remove and add all CPUs within the current length of a Set. Please note that
+// [Set.AddRange] always returns a new Set.
 //
 // See also [sched_getaffinity(2)].
 //
@@ -38,13 +48,25 @@ type Set []uint64
 
 // setsize reflects the dynamically determined size of CPUSets on this system
 // (size in uint64 words). This is usually smaller than the fixed-sized
-// [unix.CPUSet] that Go's [unix.SchedGetaffinity] uses.
+// [unix.CPUSet] that Go's [unix.SchedGetaffinity] uses. Zero until first
+// determined.
 var setsize atomic.Uint64
 var wordbytesize = uint64(unsafe.Sizeof(Set{0}[0]))
 var bitsperword = uint(wordbytesize * 8)
 
-func init() {
-	setsize.Store(1)
+// possibleSetSize returns the size of CPUSets (in uint64 words) needed to hold
+// all possible CPUs of this system. If the possible CPUs cannot be determined,
+// it returns a size of a single word.
+func possibleSetSize() uint64 {
+	possible, err := DefaultSysfs.PossibleCPUs()
+	if err != nil {
+		return 1
+	}
+	cpu, ok := possible.Max()
+	if !ok {
+		return 1
+	}
+	return uint64(setBitIndex(cpu)) + 1
 }
 
 func setBitIndex(cpu uint) int {
@@ -55,6 +77,93 @@ func setBitMask(cpu uint) uint64 {
 	return uint64(1) << (cpu % bitsperword)
 }
 
+// maxSetCPU is the highest CPU number NewSet accepts, so that malformed or
+// absurd CPU lists cannot trigger huge allocations. This still is way beyond
+// the maximum NR_CPUS the Linux kernel can be configured for.
+const maxSetCPU = 1<<24 - 1
+
+// NewSet returns a new CPU Set for the given text in list format, as described
+// in [NewList]. If the text is malformed then a [*ParseError] is returned
+// instead. CPU numbers above 16777215 are reported as [ErrCPUOutOfRange].
+//
+// In contrast to NewList(text).Set(), NewSet parses the text directly into a
+// single allocated Set, setting whole words at once for longer CPU ranges.
+func NewSet(text []byte) (Set, error) {
+	if len(text) == 0 {
+		return Set{}, nil
+	}
+	// Make a first pass to check the syntax and to find the highest CPU
+	// number, so that we can then allocate the Set exactly once and fill it in
+	// a second pass.
+	options := parseOptions{maxCPU: maxSetCPU, hasMaxCPU: true}
+	var highest uint
+	err := parseList(text, &options, func(from, to uint) {
+		highest = max(highest, to)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s := make(Set, setBitIndex(highest)+1)
+	_ = parseList(text, &options, func(from, to uint) {
+		s.fillRange(from, to)
+	})
+	return s, nil
+}
+
+// fillRange sets the CPUs from the specified range in this Set, which must
+// already be large enough to hold the range. Instead of setting individual
+// bits, fillRange sets whole words at once, where possible.
+func (s Set) fillRange(from, to uint) {
+	fromIdx, toIdx, fromMask, toMask := rangeMasks(from, to)
+	if fromIdx == toIdx {
+		s[fromIdx] |= fromMask & toMask
+		return
+	}
+	s[fromIdx] |= fromMask
+	for idx := fromIdx + 1; idx < toIdx; idx++ {
+		s[idx] = ^uint64(0)
+	}
+	s[toIdx] |= toMask
+}
+
+// clearRange clears the CPUs from the specified range in this Set, ignoring
+// any CPUs beyond the length of the Set.
+func (s Set) clearRange(from, to uint) {
+	if setBitIndex(from) >= len(s) {
+		return
+	}
+	to = min(to, uint(len(s))*bitsperword-1)
+	fromIdx, toIdx, fromMask, toMask := rangeMasks(from, to)
+	if fromIdx == toIdx {
+		s[fromIdx] &^= fromMask & toMask
+		return
+	}
+	s[fromIdx] &^= fromMask
+	for idx := fromIdx + 1; idx < toIdx; idx++ {
+		s[idx] = 0
+	}
+	s[toIdx] &^= toMask
+}
+
+// rangeMasks returns the indices of the first and last words of the specified
+// CPU range, as well as the bit masks for the first and last word.
+func rangeMasks(from, to uint) (fromIdx, toIdx int, fromMask, toMask uint64) {
+	return setBitIndex(from), setBitIndex(to),
+		^(setBitMask(from) - 1), ^uint64(0) >> (bitsperword - 1 - to%bitsperword)
+}
+
+// grow ensures that this Set is large enough to contain the specified CPU,
+// growing it in place when necessary.
+func (s *Set) grow(cpu uint) {
+	words := setBitIndex(cpu) + 1
+	if words <= len(*s) {
+		return
+	}
+	l := len(*s)
+	*s = slices.Grow(*s, words-l)[:words]
+	clear((*s)[l:])
+}
+
 // IsSet reports whether cpu is in this CPU set.
 func (s Set) IsSet(cpu uint) bool {
 	if cpu >= uint(len(s))*bitsperword {
@@ -63,20 +172,172 @@ func (s Set) IsSet(cpu uint) bool {
 	return s[setBitIndex(cpu)]&setBitMask(cpu) != 0
 }
 
+// IsEmpty returns true if this Set doesn't contain any CPUs, otherwise false.
+// Please note that an empty Set might still have a non-zero length.
+func (s Set) IsEmpty() bool {
+	for _, word := range s {
+		if word != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal returns true if this Set and another contain the same CPUs, otherwise
+// false. Trailing zero words are ignored, so Set{1} and Set{1, 0, 0} are equal.
+func (s Set) Equal(another Set) bool {
+	return slices.Equal(s.Compact(), another.Compact())
+}
+
+// Compact returns this Set without any trailing zero words. The returned Set
+// shares its backing array with this Set.
+func (s Set) Compact() Set {
+	l := len(s)
+	for l > 0 && s[l-1] == 0 {
+		l--
+	}
+	return s[:l]
+}
+
+// Key returns a comparable key representing the CPUs in this Set, suitable for
+// use in maps. Sets that are [Set.Equal] have the same key, regardless of any
+// trailing zero words. The key is an opaque binary string and not intended for
+// display purposes.
+func (s Set) Key() string {
+	s = s.Compact()
+	b := make([]byte, 0, len(s)*int(wordbytesize))
+	for _, word := range s {
+		b = binary.LittleEndian.AppendUint64(b, word)
+	}
+	return string(b)
+}
+
+// Contains reports whether cpu is in this CPU set; it is the same as
+// [Set.IsSet].
+func (s Set) Contains(cpu uint) bool {
+	return s.IsSet(cpu)
+}
+
+// Count returns the number of CPUs in this Set.
+func (s Set) Count() int {
+	count := 0
+	for _, word := range s {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// Min returns the lowest CPU in this Set and true, otherwise false if the Set is
+// empty.
+func (s Set) Min() (cpu uint, ok bool) {
+	for idx, word := range s {
+		if word != 0 {
+			return uint(idx)*bitsperword + uint(bits.TrailingZeros64(word)), true
+		}
+	}
+	return 0, false
+}
+
+// Max returns the highest CPU in this Set and true, otherwise false if the Set
+// is empty.
+func (s Set) Max() (cpu uint, ok bool) {
+	for idx := len(s) - 1; idx >= 0; idx-- {
+		if word := s[idx]; word != 0 {
+			return uint(idx)*bitsperword + uint(bits.Len64(word)) - 1, true
+		}
+	}
+	return 0, false
+}
+
+// Nth returns the CPU with the 0-based logical index n in this Set and true. If
+// there are only n or less CPUs in this Set, Nth returns false. For instance,
+// Nth(2) returns the third CPU in this Set.
+func (s Set) Nth(n int) (cpu uint, ok bool) {
+	if n < 0 {
+		return 0, false
+	}
+	for idx, word := range s {
+		count := bits.OnesCount64(word)
+		if n >= count {
+			n -= count
+			continue
+		}
+		for ; n > 0; n-- {
+			word &= word - 1 // clear lowest set bit
+		}
+		return uint(idx)*bitsperword + uint(bits.TrailingZeros64(word)), true
+	}
+	return 0, false
+}
+
+// IndexOf returns the 0-based logical index of the specified CPU within this
+// Set and true, otherwise false if the CPU is not in this Set. IndexOf is the
+// inverse of [Set.Nth].
+func (s Set) IndexOf(cpu uint) (n int, ok bool) {
+	if !s.IsSet(cpu) {
+		return 0, false
+	}
+	wordidx := setBitIndex(cpu)
+	return s[:wordidx].Count() + bits.OnesCount64(s[wordidx]&(setBitMask(cpu)-1)), true
+}
+
 // AddRange adds the CPU(s) from the specified range, returning a new Set.
 func (s Set) AddRange(from, to uint) Set {
 	if from > to {
 		panic(fmt.Sprintf("invalid range %d-%d", from, to))
 	}
-	setLen := max(to/bitsperword+1, uint(len(s))*bitsperword)
-	set := make(Set, setLen)
-	copy(set[0:len(s)], s)
-	for cpu := from; cpu <= to; cpu++ {
-		set[setBitIndex(cpu)] |= setBitMask(cpu)
-	}
+	set := make(Set, max(setBitIndex(to)+1, len(s)))
+	copy(set, s)
+	set.fillRange(from, to)
 	return set
 }
 
+// Add adds the specified CPU to this Set in place, growing the Set only when
+// necessary.
+func (s *Set) Add(cpu uint) {
+	s.grow(cpu)
+	(*s)[setBitIndex(cpu)] |= setBitMask(cpu)
+}
+
+// Remove removes the specified CPU from this Set in place. The Set never
+// shrinks.
+func (s *Set) Remove(cpu uint) {
+	if setBitIndex(cpu) < len(*s) {
+		(*s)[setBitIndex(cpu)] &^= setBitMask(cpu)
+	}
+}
+
+// RemoveRange removes the CPU(s) from the specified range from this Set in
+// place. The Set never shrinks.
+func (s *Set) RemoveRange(from, to uint) {
+	if from > to {
+		panic(fmt.Sprintf("invalid range %d-%d", from, to))
+	}
+	s.clearRange(from, to)
+}
+
+// Toggle adds the specified CPU to this Set in place if it isn't in the Set,
+// otherwise it removes the CPU from this Set.
+func (s *Set) Toggle(cpu uint) {
+	s.grow(cpu)
+	(*s)[setBitIndex(cpu)] ^= setBitMask(cpu)
+}
+
+// Clear removes all CPUs from this Set in place, keeping its length and
+// capacity for reuse.
+func (s *Set) Clear() {
+	clear(*s)
+}
+
+// Fill adds all CPUs this Set can hold with its current length in place, being
+// the counterpart to [Set.Clear]. For instance, filling a Set of length 2 adds
+// the CPUs 0-127.
+func (s *Set) Fill() {
+	for idx := range *s {
+		(*s)[idx] = ^uint64(0)
+	}
+}
+
 // IsOverlapping returns true if this Set and another overlap, otherwise false.
 func (s Set) IsOverlapping(another Set) bool {
 	for idx := range min(len(s), len(another)) {
@@ -97,6 +358,62 @@ func (s Set) Overlap(another Set) Set {
 	return overlap
 }
 
+// Union returns the union of this Set with another as a new Set.
+func (s Set) Union(another Set) Set {
+	if len(s) < len(another) {
+		s, another = another, s
+	}
+	union := make(Set, len(s))
+	copy(union, s)
+	for idx := range another {
+		union[idx] |= another[idx]
+	}
+	return union
+}
+
+// Difference returns a new Set with the CPUs from this Set that are not in
+// another Set; this is “s &^ another”, word by word.
+func (s Set) Difference(another Set) Set {
+	diff := make(Set, len(s))
+	copy(diff, s)
+	for idx := range min(len(s), len(another)) {
+		diff[idx] &^= another[idx]
+	}
+	return diff
+}
+
+// SymmetricDifference returns a new Set with the CPUs that are either in this
+// Set or in another Set, but not in both; this is “s ^ another”, word by word.
+func (s Set) SymmetricDifference(another Set) Set {
+	if len(s) < len(another) {
+		s, another = another, s
+	}
+	symdiff := make(Set, len(s))
+	copy(symdiff, s)
+	for idx := range another {
+		symdiff[idx] ^= another[idx]
+	}
+	return symdiff
+}
+
+// Complement returns a new Set with all CPUs in the range from 0 to cpus-1 that
+// are not in this Set. CPUs in this Set beyond this range are ignored.
+func (s Set) Complement(cpus uint) Set {
+	words := (cpus + bitsperword - 1) / bitsperword
+	complement := make(Set, words)
+	for idx := range complement {
+		if idx < len(s) {
+			complement[idx] = ^s[idx]
+			continue
+		}
+		complement[idx] = ^uint64(0)
+	}
+	if rem := cpus % bitsperword; rem != 0 {
+		complement[words-1] &= setBitMask(rem) - 1
+	}
+	return complement
+}
+
 // Single returns the single CPU in a Set, or otherwise false if the Set is
 // either empty or specifies multiple CPUs.
 func (s Set) Single() (cpu uint, ok bool) {
@@ -143,7 +460,9 @@ func (s Set) PinTask(tid int) error {
 // Notes:
 //   - we don't use [unix.SchedGetaffinity] as this is tied to the fixed size
 //     [unix.CPUSet] type; instead, we dynamically figure out the size needed
-//     and cache the size internally.
+//     and cache the size internally. The size is initially based on the
+//     possible CPUs of the system (see [Sysfs.PossibleCPUs]), only doubling
+//     it as long as the kernel doesn't find it sufficient.
 //   - retrieving the affinity CPU mask and then speed-running it to
 //     generate the range list is roughly two orders of magnitude faster than
 //     fetching “/proc/$PID/status” and looking for the “Cpus_allowed_list”,
@@ -153,6 +472,9 @@ func Affinity(tid int) (Set, error) {
 
 	setlenStart := setsize.Load()
 	setlen := setlenStart
+	if setlen == 0 {
+		setlen = possibleSetSize()
+	}
 	for {
 		set = make([]uint64, setlen)
 		// see also:
@@ -208,18 +530,67 @@ func SetAffinity(tid int, cpus Set) error {
 // individual CPU ranges “x-y” are separated by “,”, and single CPU ranges
 // collapsed into “x” (instead of “x-x”).
 func (s Set) String() string {
-	return s.List().String()
+	var buf [64]byte
+	return string(s.appendText(buf[:0]))
+}
+
+// AppendText appends the CPUs in this Set in textual list format, as returned
+// by [Set.String], to dst, returning the extended buffer. AppendText doesn't
+// allocate when dst has sufficient capacity.
+func (s Set) AppendText(dst []byte) ([]byte, error) {
+	return s.appendText(dst), nil
 }
 
+// appendText appends the CPUs in this Set in textual list format to dst.
+func (s Set) appendText(dst []byte) []byte {
+	first := true
+	s.ranges(func(from, to uint) bool {
+		if !first {
+			dst = append(dst, ',')
+		}
+		first = false
+		dst = appendRange(dst, from, to)
+		return true
+	})
+	return dst
+}
+
+// Set returns this Set itself, satisfying the [CPUs] interface.
+func (s Set) Set() Set { return s }
+
 // List returns the list of CPU ranges corresponding with this CPU Set.
+func (s Set) List() List {
+	return s.AppendList(List{})
+}
+
+// AppendList appends the CPU ranges of this Set to dst, returning the extended
+// List. AppendList doesn't allocate when dst has sufficient capacity, so it
+// allows reusing Lists, such as in s.AppendList(l[:0]).
+func (s Set) AppendList(dst List) List {
+	s.ranges(func(from, to uint) bool {
+		dst = append(dst, [2]uint{from, to})
+		return true
+	})
+	return dst
+}
+
+// Ranges returns an iterator over the CPU ranges in this Set, in ascending
+// order, yielding the first and last CPU of each range.
 //
 // This is an optimized implementation that does not use any division and modulo
 // operations; instead, it only uses increment and (single bit position) shift
 // operations. Additionally, this implementation fast-forwards through all-0s
 // and all-1s CPUSet words (uint64's) wherever possible.
-func (s Set) List() List {
+func (s Set) Ranges() iter.Seq2[uint, uint] {
+	return func(yield func(from, to uint) bool) {
+		s.ranges(yield)
+	}
+}
+
+// ranges yields the CPU ranges in this Set until either exhausted or yield
+// returns false.
+func (s Set) ranges(yield func(from, to uint) bool) {
 	setlen := uint64(len(s))
-	cpulist := List{}
 	cpuno := uint(0)
 	cpuwordidx := uint64(0)
 	cpuwordmask := uint64(1)
@@ -251,7 +622,7 @@ findNextCPUInWord:
 			cpuwordidx++
 		}
 		if cpuwordidx >= setlen {
-			return cpulist
+			return
 		}
 		// We arrived at a non-zero cpu mask word, so let's now find the first
 		// cpu in it.
@@ -278,7 +649,9 @@ findNextCPUInWord:
 		if cpuwordmask != 1 {
 			for {
 				if s[cpuwordidx]&cpuwordmask == 0 {
-					cpulist = append(cpulist, [2]uint{cpufrom, cpuno - 1})
+					if !yield(cpufrom, cpuno-1) {
+						return
+					}
 					continue findNextCPUInWord
 				}
 				cpuno++
@@ -299,15 +672,17 @@ findNextCPUInWord:
 		// Are we completely done? If so, add the final CPU span and then call
 		// it a day.
 		if cpuwordidx >= setlen {
-			cpulist = append(cpulist, [2]uint{cpufrom, cpuno - 1})
-			return cpulist
+			yield(cpufrom, cpuno-1)
+			return
 		}
 		// We arrived at a non-all-1s cpu mask word, so let's now find the first
 		// cpu in it that is unset. Add the CPU span, and then rinse and repeat
 		// from the beginning: find the next set CPU or fall off the disc.
 		for {
 			if s[cpuwordidx]&cpuwordmask == 0 {
-				cpulist = append(cpulist, [2]uint{cpufrom, cpuno - 1})
+				if !yield(cpufrom, cpuno-1) {
+					return
+				}
 				break
 			}
 			cpuno++
@@ -315,3 +690,27 @@ findNextCPUInWord:
 		}
 	}
 }
+
+// All returns an iterator over the individual CPUs in this Set, in ascending
+// order.
+func (s Set) All() iter.Seq[uint] {
+	return func(yield func(cpu uint) bool) {
+		for idx, word := range s {
+			for word != 0 {
+				if !yield(uint(idx)*bitsperword + uint(bits.TrailingZeros64(word))) {
+					return
+				}
+				word &= word - 1 // clear lowest set bit
+			}
+		}
+	}
+}
+
+// Collect returns a new Set with the CPUs from the specified iterator.
+func Collect(cpus iter.Seq[uint]) Set {
+	s := Set{}
+	for cpu := range cpus {
+		s.Add(cpu)
+	}
+	return s
+}
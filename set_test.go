@@ -16,9 +16,12 @@ package cpus
 
 import (
 	"bytes"
+	"errors"
 	"iter"
 	"os"
 	"runtime"
+	"slices"
+	"testing"
 
 	. "github.com/onsi/ginkgo/v2/dsl/core"
 	. "github.com/onsi/ginkgo/v2/dsl/table"
@@ -42,6 +45,19 @@ func Lines(b []byte) iter.Seq[[]byte] {
 	}
 }
 
+// Found returns the passed CPU, but fails the current test if ok is false.
+func Found(cpu uint, ok bool) uint {
+	GinkgoHelper()
+	Expect(ok).To(BeTrue(), "expected a CPU to be found")
+	return cpu
+}
+
+func FoundIndex(n int, ok bool) int {
+	GinkgoHelper()
+	Expect(ok).To(BeTrue(), "expected an index to be found")
+	return n
+}
+
 var _ = Describe("cpu sets", func() {
 
 	DescribeTable("parsing",
@@ -81,6 +97,59 @@ var _ = Describe("cpu sets", func() {
 		Entry("art", Set{0x5a0}, List{{5, 5}, {7, 8}, {10, 10}}),
 	)
 
+	DescribeTable("parsing text directly into sets",
+		func(text string) {
+			s := Successful(NewSet([]byte(text)))
+			Expect(s.String()).To(Equal(text))
+			Expect(s.List()).To(Equal(Successful(NewList([]byte(text)))))
+		},
+		Entry(nil, ""),
+		Entry(nil, "0"),
+		Entry(nil, "63"),
+		Entry(nil, "64"),
+		Entry(nil, "1-3,5"),
+		Entry(nil, "0-63"),
+		Entry(nil, "1-62"),
+		Entry(nil, "63-64"),
+		Entry(nil, "0-127"),
+		Entry(nil, "2-300,302,400-511"),
+	)
+
+	It("parses text into a set with only a single allocation", func() {
+		text := []byte("0-3,5,8-255,300-1023")
+		Expect(testing.AllocsPerRun(10, func() {
+			_, _ = NewSet(text)
+		})).To(Equal(1.0))
+	})
+
+	DescribeTable("reporting set parsing errors",
+		func(text string, kind error) {
+			_, err := NewSet([]byte(text))
+			Expect(err).To(MatchError(kind))
+			_, listerr := NewList([]byte(text))
+			Expect(err).To(Equal(listerr))
+		},
+		Entry(nil, "abc", ErrExpectedNumber),
+		Entry(nil, "1-3,", ErrExpectedNumber),
+		Entry(nil, "3-1", ErrInvertedRange),
+		Entry(nil, "1-3:1/2", ErrExpectedSeparator),
+		Entry(nil, "0-99999999999999999999999", ErrCPUOutOfRange),
+		Entry(nil, "x,1152921504606846975", ErrExpectedNumber),
+	)
+
+	DescribeTable("refusing absurdly large sets",
+		func(text string, offset int) {
+			_, err := NewSet([]byte(text))
+			Expect(err).To(MatchError(ErrCPUOutOfRange))
+			var perr *ParseError
+			Expect(errors.As(err, &perr)).To(BeTrue())
+			Expect(perr.Offset).To(Equal(offset))
+		},
+		Entry(nil, "18446744073709551615", 0),
+		Entry(nil, "1,2-1152921504606846975", 4),
+		Entry(nil, "16777216", 0),
+	)
+
 	It("gets this process's CPU affinity list, consistent with /proc/self/status data", func() {
 		Expect(wordbytesize).To(Equal(uint64(64 /* bits in uint64 */ / 8 /* bits/byte*/)))
 		cpulist := Successful(Affinity(os.Getpid())).List()
@@ -93,7 +162,7 @@ var _ = Describe("cpu sets", func() {
 			if !bytes.HasPrefix(line, prefix) {
 				continue
 			}
-			allowedList = Successful(NewList(line[len(prefix) : len(line)-1]))
+			allowedList = Successful(ParseListLenient(line[len(prefix):]))
 		}
 		Expect(cpulist).To(Equal(allowedList))
 	})
@@ -127,6 +196,29 @@ var _ = Describe("cpu sets", func() {
 			Expect(s.String()).To(Equal("1-2,64"))
 		})
 
+		It("appends textual representations without allocations", func() {
+			s := Set{6, 1}
+			Expect(s.AppendText([]byte("cpus: "))).To(Equal([]byte("cpus: 1-2,64")))
+			buf := make([]byte, 0, 64)
+			Expect(testing.AllocsPerRun(10, func() {
+				_, _ = s.AppendText(buf[:0])
+			})).To(BeZero())
+			Expect(testing.AllocsPerRun(10, func() {
+				_ = s.String()
+			})).To(BeNumerically("<=", 1))
+		})
+
+		It("appends ranges to lists without allocations", func() {
+			s := Set{6, 1}
+			Expect(s.AppendList(List{{0, 0}})).To(Equal(List{{0, 0}, {1, 2}, {64, 64}}))
+			Expect(Set{}.AppendList(nil)).To(BeEmpty())
+			l := make(List, 0, 4)
+			Expect(testing.AllocsPerRun(10, func() {
+				l = s.AppendList(l[:0])
+			})).To(BeZero())
+			Expect(l).To(Equal(List{{1, 2}, {64, 64}}))
+		})
+
 	})
 
 	When("testing CPUs in sets", func() {
@@ -172,6 +264,60 @@ var _ = Describe("cpu sets", func() {
 		Entry(nil, "1-5", "3-9", "3-5"),
 	)
 
+	DescribeTable("calculating union",
+		func(s1, s2 Set, union string) {
+			Expect(s1.Union(s2).String()).To(Equal(union))
+		},
+		Entry(nil, Set{}, Set{}, ""),
+		Entry(nil, Set{0x6}, nil, "1-2"),
+		Entry(nil, nil, Set{0x6}, "1-2"),
+		Entry(nil, Set{0x6}, Set{0x18}, "1-4"),
+		Entry(nil, Set{0x1}, Set{0, 0x1}, "0,64"),
+		Entry(nil, Set{0, 0x1}, Set{0x1}, "0,64"),
+	)
+
+	DescribeTable("calculating difference",
+		func(s1, s2 Set, diff string) {
+			Expect(s1.Difference(s2).String()).To(Equal(diff))
+		},
+		Entry(nil, Set{}, Set{}, ""),
+		Entry(nil, Set{0x6}, nil, "1-2"),
+		Entry(nil, nil, Set{0x6}, ""),
+		Entry(nil, Set{0x1e}, Set{0x6}, "3-4"),
+		Entry(nil, Set{0x1, 0x1}, Set{0x1}, "64"),
+		Entry(nil, Set{0x1}, Set{0x1, 0x1}, ""),
+	)
+
+	It("subtracts sets returned by Affinity", func() {
+		affs := Successful(Affinity(0))
+		Expect(affs.Difference(affs).List()).To(BeEmpty())
+		Expect(affs.Difference(Set{}).List()).To(Equal(affs.List()))
+	})
+
+	DescribeTable("calculating symmetric difference",
+		func(s1, s2 Set, symdiff string) {
+			Expect(s1.SymmetricDifference(s2).String()).To(Equal(symdiff))
+		},
+		Entry(nil, Set{}, Set{}, ""),
+		Entry(nil, Set{0x6}, nil, "1-2"),
+		Entry(nil, nil, Set{0x6}, "1-2"),
+		Entry(nil, Set{0x1e}, Set{0x3}, "0,2-4"),
+		Entry(nil, Set{0x1}, Set{0x1, 0x1}, "64"),
+	)
+
+	DescribeTable("calculating complement",
+		func(s Set, cpus uint, complement string) {
+			Expect(s.Complement(cpus).String()).To(Equal(complement))
+		},
+		Entry(nil, Set{}, uint(0), ""),
+		Entry(nil, Set{}, uint(8), "0-7"),
+		Entry(nil, Set{0x6}, uint(8), "0,3-7"),
+		Entry(nil, Set{0xff}, uint(8), ""),
+		Entry(nil, Set{0x6}, uint(64), "0,3-63"),
+		Entry(nil, Set{0x6}, uint(66), "0,3-65"),
+		Entry(nil, Set{0x6, ^uint64(0)}, uint(4), "0,3"),
+	)
+
 	DescribeTable("determining a single CPU in Set",
 		func(l string, trailers bool, cpu int, ok bool) {
 			s := Successful(NewList([]byte(l))).Set()
@@ -197,10 +343,194 @@ var _ = Describe("cpu sets", func() {
 			Expect(Set{0, 0, 0}.AddRange(63, 65).String()).To(Equal("63-65"))
 		})
 
+		It("doesn't modify the original Set", func() {
+			s := Set{0x1}
+			Expect(s.AddRange(64, 64)).To(Equal(Set{0x1, 0x1}))
+			Expect(s).To(Equal(Set{0x1}))
+		})
+
 		It("panics on invalid range", func() {
 			Expect(func() {
 				Set{}.AddRange(3, 1)
 			}).To(Panic())
+			var s Set
+			Expect(func() { s.RemoveRange(3, 1) }).To(Panic())
+		})
+
+	})
+
+	When("comparing sets", func() {
+
+		DescribeTable("checking for empty sets",
+			func(s Set, empty bool) {
+				Expect(s.IsEmpty()).To(Equal(empty))
+			},
+			Entry(nil, nil, true),
+			Entry(nil, Set{}, true),
+			Entry(nil, Set{0, 0}, true),
+			Entry(nil, Set{0, 1}, false),
+		)
+
+		DescribeTable("compacting",
+			func(s Set, expected Set) {
+				Expect(s.Compact()).To(Equal(expected))
+			},
+			Entry(nil, Set{}, Set{}),
+			Entry(nil, Set{0, 0}, Set{}),
+			Entry(nil, Set{1, 0, 0}, Set{1}),
+			Entry(nil, Set{0, 1}, Set{0, 1}),
+		)
+
+		DescribeTable("testing for equality",
+			func(s1, s2 Set, equal bool) {
+				Expect(s1.Equal(s2)).To(Equal(equal))
+				Expect(s2.Equal(s1)).To(Equal(equal))
+				Expect(s1.Key() == s2.Key()).To(Equal(equal))
+			},
+			Entry(nil, nil, Set{}, true),
+			Entry(nil, Set{}, Set{0, 0}, true),
+			Entry(nil, Set{1}, Set{1, 0, 0}, true),
+			Entry(nil, Set{1}, Set{2}, false),
+			Entry(nil, Set{1}, Set{1, 1}, false),
+			Entry(nil, Set{0, 1}, Set{1}, false),
+		)
+
+		It("dedupes sets in maps", func() {
+			m := map[string]Set{}
+			for _, s := range []Set{{1}, {1, 0}, {0, 1}, {0, 1, 0, 0}, {}} {
+				m[s.Key()] = s
+			}
+			Expect(m).To(HaveLen(3))
+		})
+
+	})
+
+	When("iterating", func() {
+
+		DescribeTable("iterating over all CPUs",
+			func(s Set, expected []uint) {
+				Expect(slices.Collect(s.All())).To(Equal(expected))
+			},
+			Entry(nil, nil, nil),
+			Entry(nil, Set{0, 0}, nil),
+			Entry(nil, Set{0x16}, []uint{1, 2, 4}),
+			Entry(nil, Set{1 << 63, 0x1, 0, 0x2}, []uint{63, 64, 193}),
+		)
+
+		It("iterates over all ranges", func() {
+			s := Successful(NewSet([]byte("0-3,5,63-64,200-300")))
+			var ranges List
+			for from, to := range s.Ranges() {
+				ranges = append(ranges, [2]uint{from, to})
+			}
+			Expect(ranges).To(Equal(List{{0, 3}, {5, 5}, {63, 64}, {200, 300}}))
+		})
+
+		It("stops iterating early", func() {
+			s := Successful(NewSet([]byte("1,3,5-7,63-64,100-200")))
+			for _, n := range []int{1, 2, 3, 4} {
+				var ranges List
+				for from, to := range s.Ranges() {
+					ranges = append(ranges, [2]uint{from, to})
+					if len(ranges) == n {
+						break
+					}
+				}
+				Expect(ranges).To(HaveLen(n))
+			}
+			var cpus []uint
+			for cpu := range s.All() {
+				cpus = append(cpus, cpu)
+				if len(cpus) == 2 {
+					break
+				}
+			}
+			Expect(cpus).To(Equal([]uint{1, 3}))
+		})
+
+		It("collects CPUs into sets", func() {
+			Expect(Collect(slices.Values([]uint{})).String()).To(BeEmpty())
+			Expect(Collect(slices.Values([]uint{200, 1, 2, 3, 64})).String()).To(
+				Equal("1-3,64,200"))
+			s := Successful(NewSet([]byte("0-3,5,8-255,300")))
+			Expect(Collect(s.All()).List()).To(Equal(s.List()))
+		})
+
+	})
+
+	When("mutating sets in place", func() {
+
+		It("adds and removes single CPUs", func() {
+			var s Set
+			s.Add(1)
+			s.Add(65)
+			Expect(s.String()).To(Equal("1,65"))
+			Expect(s).To(HaveLen(2))
+			s.Remove(65)
+			s.Remove(666)
+			Expect(s.String()).To(Equal("1"))
+			Expect(s).To(HaveLen(2))
+		})
+
+		It("toggles CPUs", func() {
+			var s Set
+			s.Toggle(2)
+			s.Toggle(64)
+			Expect(s.String()).To(Equal("2,64"))
+			s.Toggle(2)
+			Expect(s.String()).To(Equal("64"))
+		})
+
+		DescribeTable("filling sets",
+			func(initial Set, expected string) {
+				initial.Fill()
+				Expect(initial.String()).To(Equal(expected))
+			},
+			Entry(nil, nil, ""),
+			Entry(nil, Set{}, ""),
+			Entry(nil, Set{0x1}, "0-63"),
+			Entry(nil, Set{0, 0, 0x1}, "0-191"),
+		)
+
+		DescribeTable("removing ranges",
+			func(initial string, from, to int, expected string) {
+				s := Successful(NewSet([]byte(initial)))
+				s.RemoveRange(uint(from), uint(to))
+				Expect(s.String()).To(Equal(expected))
+			},
+			Entry(nil, "", 0, 100, ""),
+			Entry(nil, "0-7", 2, 3, "0-1,4-7"),
+			Entry(nil, "0-255", 1, 254, "0,255"),
+			Entry(nil, "0-127", 64, 1000, "0-63"),
+			Entry(nil, "0-127", 128, 1000, "0-127"),
+		)
+
+		It("grows sets within their capacity without leaking stale CPUs", func() {
+			backing := Set{^uint64(0), ^uint64(0), ^uint64(0)}
+			s := backing[:1]
+			s.Clear()
+			s.Add(129)
+			Expect(s.String()).To(Equal("129"))
+			Expect(&s[0]).To(BeIdenticalTo(&backing[0]))
+		})
+
+		It("clears and fills sets", func() {
+			s := Set{0x3, 0x1}
+			s.Clear()
+			Expect(s).To(Equal(Set{0, 0}))
+			s.Fill()
+			Expect(s).To(Equal(Set{^uint64(0), ^uint64(0)}))
+		})
+
+		It("adds without allocations when the set is large enough", func() {
+			s := make(Set, 4)
+			Expect(testing.AllocsPerRun(10, func() {
+				s.Fill()
+				s.RemoveRange(3, 200)
+				s.Add(42)
+				s.Toggle(43)
+				s.Clear()
+			})).To(BeZero())
 		})
 
 	})
@@ -0,0 +1,96 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Sysfs is the path to the root of a sysfs hierarchy to discover the CPUs of a
+// system from. Usually, this is [DefaultSysfs]. However, when running inside a
+// container with the host's sysfs mounted elsewhere, or when testing with
+// fixture hierarchies, Sysfs allows reading from a different root.
+type Sysfs string
+
+// DefaultSysfs is where sysfs is usually mounted.
+const DefaultSysfs Sysfs = "/sys"
+
+// sysCPUDir is the sysfs directory with the CPU information, relative to the
+// sysfs root.
+const sysCPUDir = "devices/system/cpu"
+
+// path returns the path of the specified elements joined to this sysfs root.
+func (fs Sysfs) path(elem ...string) string {
+	return filepath.Join(append([]string{string(fs)}, elem...)...)
+}
+
+// readList returns the CPU List from the sysfs file with the specified path
+// elements.
+func (fs Sysfs) readList(elem ...string) (List, error) {
+	return ReadList(fs.path(elem...))
+}
+
+// readOptionalList returns the CPU List from the sysfs file with the specified
+// path elements, or an empty List if the file doesn't exist. Files containing
+// just “(null)”, as some kernels render unallocated CPU masks, are taken as an
+// empty List, too.
+func (fs Sysfs) readOptionalList(elem ...string) (List, error) {
+	path := fs.path(elem...)
+	text, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return List{}, nil
+		}
+		return nil, fmt.Errorf("cannot read CPU list, %w", err)
+	}
+	text = bytes.TrimSpace(text)
+	if string(text) == "(null)" {
+		return List{}, nil
+	}
+	l, err := NewList(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CPU list in %q, %w", path, err)
+	}
+	return l, nil
+}
+
+// readString returns the contents of the sysfs file with the specified path
+// elements, without any surrounding white space.
+func (fs Sysfs) readString(elem ...string) (string, error) {
+	text, err := os.ReadFile(fs.path(elem...))
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSpace(text)), nil
+}
+
+// readInt returns the decimal integer number from the sysfs file with the
+// specified path elements.
+func (fs Sysfs) readInt(elem ...string) (int, error) {
+	text, err := fs.readString(elem...)
+	if err != nil {
+		return 0, err
+	}
+	num, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number in %q, %w", fs.path(elem...), err)
+	}
+	return num, nil
+}
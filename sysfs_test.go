@@ -0,0 +1,75 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+// sysfsFiles maps file paths relative to a sysfs root to their contents.
+type sysfsFiles map[string]string
+
+// fakeSysfs returns the root of a new sysfs fixture hierarchy in a temporary
+// directory, populated with the specified files. A trailing newline is added
+// to all file contents, as sysfs does.
+func fakeSysfs(files ...sysfsFiles) Sysfs {
+	GinkgoHelper()
+	root := GinkgoT().TempDir()
+	for _, files := range files {
+		for path, contents := range files {
+			path = filepath.Join(root, path)
+			Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+			Expect(os.WriteFile(path, []byte(contents+"\n"), 0o644)).To(Succeed())
+		}
+	}
+	return Sysfs(root)
+}
+
+var _ = Describe("sysfs", func() {
+
+	It("reads optional CPU lists", func() {
+		fs := fakeSysfs(sysfsFiles{
+			"a": "1-3",
+			"b": "(null)",
+			"c": "",
+			"d": "1-",
+		})
+		Expect(fs.readOptionalList("a")).To(Equal(List{{1, 3}}))
+		Expect(fs.readOptionalList("b")).To(Equal(List{}))
+		Expect(fs.readOptionalList("c")).To(Equal(List{}))
+		Expect(fs.readOptionalList("nada")).To(Equal(List{}))
+		Expect(fs.readOptionalList("d")).Error().To(SatisfyAll(
+			MatchError(ErrExpectedNumber),
+			MatchError(ContainSubstring(fs.path("d")))))
+		Expect(fs.readOptionalList()).Error().To(HaveOccurred())
+	})
+
+	It("reads numbers", func() {
+		fs := fakeSysfs(sysfsFiles{
+			"a": "42",
+			"b": "-1",
+			"c": "x",
+		})
+		Expect(fs.readInt("a")).To(Equal(42))
+		Expect(fs.readInt("b")).To(Equal(-1))
+		Expect(fs.readInt("c")).Error().To(MatchError(ContainSubstring(fs.path("c"))))
+		Expect(fs.readInt("nada")).Error().To(MatchError(os.ErrNotExist))
+	})
+
+})
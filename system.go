@@ -0,0 +1,116 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import "fmt"
+
+// SystemCPUs describes the states of the CPUs of a system, as documented in
+// [CPU topology].
+//
+// [CPU topology]: https://www.kernel.org/doc/html/latest/admin-guide/cputopology.html
+type SystemCPUs struct {
+	// KernelMax is the maximum CPU number the kernel is configured for, that is,
+	// NR_CPUS-1.
+	KernelMax uint
+	// Possible CPUs have been allocated resources and can be brought online
+	// when present.
+	Possible List
+	// Present CPUs have been identified as being present in the system.
+	Present List
+	// Online CPUs are being scheduled.
+	Online List
+	// Offline CPUs are not online, either because they have been taken
+	// offline or they are beyond the kernel's maximum CPU.
+	Offline List
+	// Isolated CPUs are isolated from the scheduler using “isolcpus=”.
+	Isolated List
+	// NohzFull CPUs are in adaptive-tick mode using “nohz_full=”.
+	NohzFull List
+}
+
+// SystemCPUs returns the states of the CPUs of the system. Otherwise, it
+// returns an error.
+func (fs Sysfs) SystemCPUs() (*SystemCPUs, error) {
+	var sys SystemCPUs
+	var err error
+	if sys.KernelMax, err = fs.KernelMax(); err != nil {
+		return nil, err
+	}
+	for _, state := range []struct {
+		l    *List
+		read func() (List, error)
+	}{
+		{&sys.Possible, fs.PossibleCPUs},
+		{&sys.Present, fs.PresentCPUs},
+		{&sys.Online, fs.OnlineCPUs},
+		{&sys.Offline, fs.OfflineCPUs},
+		{&sys.Isolated, fs.IsolatedCPUs},
+		{&sys.NohzFull, fs.NohzFullCPUs},
+	} {
+		if *state.l, err = state.read(); err != nil {
+			return nil, err
+		}
+	}
+	return &sys, nil
+}
+
+// KernelMax returns the maximum CPU number the kernel is configured for, that
+// is, NR_CPUS-1.
+func (fs Sysfs) KernelMax() (uint, error) {
+	kernelMax, err := fs.readInt(sysCPUDir, "kernel_max")
+	if err != nil {
+		return 0, err
+	}
+	if kernelMax < 0 {
+		return 0, fmt.Errorf("%w %d", ErrNegativeCPU, kernelMax)
+	}
+	return uint(kernelMax), nil
+}
+
+// PossibleCPUs returns the CPUs that have been allocated resources and can be
+// brought online when present.
+func (fs Sysfs) PossibleCPUs() (List, error) {
+	return fs.readList(sysCPUDir, "possible")
+}
+
+// PresentCPUs returns the CPUs that have been identified as being present in
+// the system.
+func (fs Sysfs) PresentCPUs() (List, error) {
+	return fs.readList(sysCPUDir, "present")
+}
+
+// OnlineCPUs returns the CPUs that are online and being scheduled.
+func (fs Sysfs) OnlineCPUs() (List, error) {
+	return fs.readList(sysCPUDir, "online")
+}
+
+// OfflineCPUs returns the CPUs that are not online.
+func (fs Sysfs) OfflineCPUs() (List, error) {
+	return fs.readList(sysCPUDir, "offline")
+}
+
+// IsolatedCPUs returns the CPUs isolated from the scheduler using the
+// “isolcpus=” boot parameter. On kernels not supporting this information, the
+// List is empty.
+func (fs Sysfs) IsolatedCPUs() (List, error) {
+	return fs.readOptionalList(sysCPUDir, "isolated")
+}
+
+// NohzFullCPUs returns the CPUs in adaptive-tick mode using the “nohz_full=”
+// boot parameter. On kernels configured without CONFIG_NO_HZ_FULL, the List is
+// empty.
+func (fs Sysfs) NohzFullCPUs() (List, error) {
+	return fs.readOptionalList(sysCPUDir, "nohz_full")
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+// systemFixture is a system with 8 possible CPUs of which 6 are present and 4
+// online, with some isolated and adaptive-tick CPUs.
+var systemFixture = sysfsFiles{
+	"devices/system/cpu/kernel_max": "8191",
+	"devices/system/cpu/possible":   "0-7",
+	"devices/system/cpu/present":    "0-5",
+	"devices/system/cpu/online":     "0-2,4",
+	"devices/system/cpu/offline":    "3,5-8191",
+	"devices/system/cpu/isolated":   "2,4",
+	"devices/system/cpu/nohz_full":  "4",
+}
+
+var _ = Describe("system CPUs", func() {
+
+	It("reads the CPU states", func() {
+		sys := Successful(fakeSysfs(systemFixture).SystemCPUs())
+		Expect(sys).To(Equal(&SystemCPUs{
+			KernelMax: 8191,
+			Possible:  List{{0, 7}},
+			Present:   List{{0, 5}},
+			Online:    List{{0, 2}, {4, 4}},
+			Offline:   List{{3, 3}, {5, 8191}},
+			Isolated:  List{{2, 2}, {4, 4}},
+			NohzFull:  List{{4, 4}},
+		}))
+	})
+
+	It("handles missing and unset isolated and adaptive-tick CPUs", func() {
+		fs := fakeSysfs(systemFixture, sysfsFiles{
+			"devices/system/cpu/isolated":  "",
+			"devices/system/cpu/nohz_full": "(null)",
+		})
+		Expect(fs.IsolatedCPUs()).To(BeEmpty())
+		Expect(fs.NohzFullCPUs()).To(BeEmpty())
+		Expect(os.Remove(fs.path(sysCPUDir, "nohz_full"))).To(Succeed())
+		Expect(fs.NohzFullCPUs()).To(BeEmpty())
+		Expect(fs.SystemCPUs()).Error().NotTo(HaveOccurred())
+	})
+
+	It("reports errors", func() {
+		fs := fakeSysfs(systemFixture, sysfsFiles{
+			"devices/system/cpu/online": "0-",
+		})
+		Expect(fs.SystemCPUs()).Error().To(MatchError(ErrExpectedNumber))
+
+		fs = fakeSysfs(systemFixture, sysfsFiles{
+			"devices/system/cpu/kernel_max": "-1",
+		})
+		Expect(fs.SystemCPUs()).Error().To(MatchError(ErrNegativeCPU))
+
+		Expect(Sysfs(GinkgoT().TempDir()).SystemCPUs()).Error().To(MatchError(os.ErrNotExist))
+		fs = fakeSysfs(sysfsFiles{"devices/system/cpu/kernel_max": "1"})
+		Expect(fs.SystemCPUs()).Error().To(MatchError(os.ErrNotExist))
+	})
+
+	It("reads this system's CPUs", func() {
+		sys := Successful(DefaultSysfs.SystemCPUs())
+		Expect(sys.Online).NotTo(BeEmpty())
+		Expect(sys.Possible.Count()).To(BeNumerically(">=", sys.Online.Count()))
+		Expect(Found(sys.Possible.Max())).To(BeNumerically("<=", sys.KernelMax))
+	})
+
+	It("sizes affinity sets based on the possible CPUs", func() {
+		possible := Successful(DefaultSysfs.PossibleCPUs())
+		Expect(possibleSetSize()).To(Equal(uint64(setBitIndex(Found(possible.Max())) + 1)))
+	})
+
+})
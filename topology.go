@@ -0,0 +1,225 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// CPUTopology describes where a single logical CPU is located in the topology
+// of a system, as documented in [CPU topology].
+//
+// The kernel reports IDs of -1 when the architecture or platform doesn't
+// provide them; the same applies when a kernel predates a particular topology
+// file. The IDs of dies, clusters and cores are only unique within their
+// package.
+//
+// [CPU topology]: https://www.kernel.org/doc/html/latest/admin-guide/cputopology.html
+type CPUTopology struct {
+	CPU     uint // logical CPU number.
+	Package int  // physical package (socket) ID.
+	Die     int  // die ID inside the package.
+	Cluster int  // cluster ID, such as a group of cores sharing an L2 cache.
+	Core    int  // core ID inside the package.
+	// ThreadSiblings are the hardware threads (SMT siblings) of the same core,
+	// including this CPU itself.
+	ThreadSiblings List
+	// CoreSiblings are the CPUs in the same package, including this CPU itself.
+	CoreSiblings List
+	// DieCPUs are the CPUs in the same die, including this CPU itself.
+	DieCPUs List
+	// PackageCPUs are the CPUs in the same package, including this CPU itself.
+	PackageCPUs List
+}
+
+// Topology is the topology of the online CPUs of a system.
+type Topology struct {
+	// CPUs are the topology records of the online CPUs, in ascending order of
+	// their CPU numbers.
+	CPUs []CPUTopology
+}
+
+// Topology returns the topology of the online CPUs of the system. Otherwise, it
+// returns an error.
+func (fs Sysfs) Topology() (*Topology, error) {
+	online, err := fs.OnlineCPUs()
+	if err != nil {
+		return nil, err
+	}
+	topo := &Topology{CPUs: make([]CPUTopology, 0, online.Count())}
+	for cpu := range online.All() {
+		cputopo, err := fs.CPUTopology(cpu)
+		if err != nil {
+			return nil, err
+		}
+		topo.CPUs = append(topo.CPUs, *cputopo)
+	}
+	return topo, nil
+}
+
+// CPUTopology returns the topology information of the specified logical CPU.
+// Otherwise, it returns an error, such as when the CPU is offline.
+func (fs Sysfs) CPUTopology(cpu uint) (*CPUTopology, error) {
+	dir := filepath.Join(cpuDir(cpu), "topology")
+	cputopo := CPUTopology{CPU: cpu}
+	var err error
+	for _, id := range []struct {
+		id       *int
+		name     string
+		optional bool
+	}{
+		{&cputopo.Package, "physical_package_id", false},
+		{&cputopo.Die, "die_id", true},
+		{&cputopo.Cluster, "cluster_id", true},
+		{&cputopo.Core, "core_id", false},
+	} {
+		if *id.id, err = fs.readInt(dir, id.name); err != nil {
+			if !id.optional || !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			*id.id = -1
+		}
+	}
+	if cputopo.ThreadSiblings, err = fs.readList(dir, "thread_siblings_list"); err != nil {
+		return nil, err
+	}
+	if cputopo.CoreSiblings, err = fs.readList(dir, "core_siblings_list"); err != nil {
+		return nil, err
+	}
+	// Kernels before 5.6 don't have the die and package CPU lists; as these
+	// kernels don't know about dies, all CPUs of a package are on the same die.
+	if cputopo.PackageCPUs, err = fs.readOptionalList(dir, "package_cpus_list"); err != nil {
+		return nil, err
+	}
+	if len(cputopo.PackageCPUs) == 0 {
+		cputopo.PackageCPUs = cputopo.CoreSiblings
+	}
+	if cputopo.DieCPUs, err = fs.readOptionalList(dir, "die_cpus_list"); err != nil {
+		return nil, err
+	}
+	if len(cputopo.DieCPUs) == 0 {
+		cputopo.DieCPUs = cputopo.PackageCPUs
+	}
+	return &cputopo, nil
+}
+
+// cpuDir returns the sysfs directory of the specified logical CPU, relative to
+// the sysfs root.
+func cpuDir(cpu uint) string {
+	return filepath.Join(sysCPUDir, "cpu"+strconv.FormatUint(uint64(cpu), 10))
+}
+
+// CPU returns the topology record of the specified CPU, or false if the CPU is
+// not part of this topology.
+func (t *Topology) CPU(cpu uint) (*CPUTopology, bool) {
+	idx, ok := slices.BinarySearchFunc(t.CPUs, cpu, func(c CPUTopology, cpu uint) int {
+		switch {
+		case c.CPU < cpu:
+			return -1
+		case c.CPU > cpu:
+			return 1
+		}
+		return 0
+	})
+	if !ok {
+		return nil, false
+	}
+	return &t.CPUs[idx], true
+}
+
+// List returns the List of all CPUs in this topology.
+func (t *Topology) List() List {
+	return t.collect(func(*CPUTopology) bool { return true })
+}
+
+// SiblingsOf returns the online hardware threads (SMT siblings) sharing the
+// same core with the specified CPU, including the CPU itself, as reported by
+// the kernel. If the CPU isn't part of this topology, the List is empty.
+func (t *Topology) SiblingsOf(cpu uint) List {
+	c, ok := t.CPU(cpu)
+	if !ok {
+		return List{}
+	}
+	return c.ThreadSiblings.Overlap(t.List())
+}
+
+// CPUsOfPackage returns the CPUs in the package with the specified ID.
+func (t *Topology) CPUsOfPackage(id int) List {
+	return t.collect(func(c *CPUTopology) bool { return c.Package == id })
+}
+
+// CPUsOfDie returns the CPUs in the specified die of the specified package.
+func (t *Topology) CPUsOfDie(pkg, die int) List {
+	return t.collect(func(c *CPUTopology) bool {
+		return c.Package == pkg && c.Die == die
+	})
+}
+
+// CPUsOfCluster returns the CPUs in the specified cluster of the specified
+// package.
+func (t *Topology) CPUsOfCluster(pkg, cluster int) List {
+	return t.collect(func(c *CPUTopology) bool {
+		return c.Package == pkg && c.Cluster == cluster
+	})
+}
+
+// CPUsOfCore returns the CPUs, that is, hardware threads, of the core with the
+// specified ID in the specified package. If the kernel doesn't report package
+// or core IDs, the List is empty.
+//
+// Please note that on some ARM systems core IDs are unique only within their
+// clusters, so CPUsOfCore then returns the CPUs of multiple cores. Use
+// [Topology.SiblingsOf] to reliably find the CPUs of the same core.
+func (t *Topology) CPUsOfCore(pkg, core int) List {
+	if pkg < 0 || core < 0 {
+		return List{}
+	}
+	return t.collect(func(c *CPUTopology) bool {
+		return c.Package == pkg && c.Core == core
+	})
+}
+
+// Packages returns the IDs of the packages in this topology, in ascending
+// order.
+func (t *Topology) Packages() []int {
+	var ids []int
+	for idx := range t.CPUs {
+		ids = append(ids, t.CPUs[idx].Package)
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// collect returns the List of CPUs with their topology records matching the
+// specified predicate.
+func (t *Topology) collect(match func(*CPUTopology) bool) List {
+	l := List{}
+	for idx := range t.CPUs {
+		c := &t.CPUs[idx]
+		if !match(c) {
+			continue
+		}
+		if last := len(l) - 1; last >= 0 && l[last][1]+1 == c.CPU {
+			l[last][1] = c.CPU
+			continue
+		}
+		l = append(l, [2]uint{c.CPU, c.CPU})
+	}
+	return l
+}
@@ -0,0 +1,187 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package cpus
+
+import (
+	"fmt"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+// topologyFixture returns the sysfs topology files of a system with two
+// packages of two cores each, with two hardware threads per core. The CPUs are
+// enumerated first by thread, then by package, and then by core, so that CPUs
+// 0-3 are the first threads of all cores.
+func topologyFixture() sysfsFiles {
+	files := sysfsFiles{
+		"devices/system/cpu/online": "0-7",
+	}
+	for cpu := range 8 {
+		pkg := cpu / 2 % 2
+		dir := fmt.Sprintf("devices/system/cpu/cpu%d/topology/", cpu)
+		files[dir+"physical_package_id"] = fmt.Sprint(pkg)
+		files[dir+"die_id"] = "0"
+		files[dir+"cluster_id"] = fmt.Sprint(cpu % 4)
+		files[dir+"core_id"] = fmt.Sprint(cpu % 2)
+		files[dir+"thread_siblings_list"] = fmt.Sprintf("%d,%d", cpu%4, cpu%4+4)
+		pkgcpus := fmt.Sprintf("%d-%d,%d-%d", pkg*2, pkg*2+1, pkg*2+4, pkg*2+5)
+		files[dir+"core_siblings_list"] = pkgcpus
+		files[dir+"die_cpus_list"] = pkgcpus
+		files[dir+"package_cpus_list"] = pkgcpus
+	}
+	return files
+}
+
+// flatTopologyFixture returns the sysfs topology files of a single-package
+// system without SMT, with the specified number of CPUs, where the CPU's
+// cluster and core IDs are derived from the CPU number using the specified
+// functions.
+func flatTopologyFixture(cpus int, cluster, core func(cpu int) int) sysfsFiles {
+	files := sysfsFiles{
+		"devices/system/cpu/online": fmt.Sprintf("0-%d", cpus-1),
+	}
+	for cpu := range cpus {
+		dir := fmt.Sprintf("devices/system/cpu/cpu%d/topology/", cpu)
+		files[dir+"physical_package_id"] = "0"
+		files[dir+"die_id"] = "-1"
+		files[dir+"cluster_id"] = fmt.Sprint(cluster(cpu))
+		files[dir+"core_id"] = fmt.Sprint(core(cpu))
+		files[dir+"thread_siblings_list"] = fmt.Sprint(cpu)
+		files[dir+"core_siblings_list"] = fmt.Sprintf("0-%d", cpus-1)
+	}
+	return files
+}
+
+var _ = Describe("CPU topology", func() {
+
+	It("discovers the topology", func() {
+		topo := Successful(fakeSysfs(topologyFixture()).Topology())
+		Expect(topo.CPUs).To(HaveLen(8))
+		Expect(topo.List()).To(Equal(List{{0, 7}}))
+		c6, ok := topo.CPU(6)
+		Expect(ok).To(BeTrue())
+		Expect(c6).To(Equal(&CPUTopology{
+			CPU:            6,
+			Package:        1,
+			Die:            0,
+			Cluster:        2,
+			Core:           0,
+			ThreadSiblings: List{{2, 2}, {6, 6}},
+			CoreSiblings:   List{{2, 3}, {6, 7}},
+			DieCPUs:        List{{2, 3}, {6, 7}},
+			PackageCPUs:    List{{2, 3}, {6, 7}},
+		}))
+		Expect(topo.CPU(8)).Error().To(BeFalse())
+
+		Expect(topo.Packages()).To(Equal([]int{0, 1}))
+		Expect(topo.SiblingsOf(5)).To(Equal(List{{1, 1}, {5, 5}}))
+		Expect(topo.SiblingsOf(42)).To(BeEmpty())
+		Expect(topo.CPUsOfPackage(1)).To(Equal(List{{2, 3}, {6, 7}}))
+		Expect(topo.CPUsOfPackage(2)).To(BeEmpty())
+		Expect(topo.CPUsOfDie(0, 0)).To(Equal(List{{0, 1}, {4, 5}}))
+		Expect(topo.CPUsOfCluster(1, 3)).To(Equal(List{{3, 3}, {7, 7}}))
+		Expect(topo.CPUsOfCore(1, 1)).To(Equal(List{{3, 3}, {7, 7}}))
+		Expect(topo.CPUsOfCore(0, 2)).To(BeEmpty())
+	})
+
+	It("discovers the topology of only the online CPUs", func() {
+		topo := Successful(fakeSysfs(topologyFixture(), sysfsFiles{
+			"devices/system/cpu/online": "0-3",
+		}).Topology())
+		Expect(topo.List()).To(Equal(List{{0, 3}}))
+		Expect(topo.SiblingsOf(1)).To(Equal(List{{1, 1}}))
+	})
+
+	It("finds siblings without core IDs", func() {
+		topo := Successful(fakeSysfs(flatTopologyFixture(4,
+			func(int) int { return -1 },
+			func(int) int { return -1 })).Topology())
+		Expect(topo.SiblingsOf(1)).To(Equal(List{{1, 1}}))
+		Expect(topo.CPUsOfCore(0, -1)).To(BeEmpty())
+		Expect(topo.CPUsOfCore(-1, 0)).To(BeEmpty())
+		Expect(topo.CPUsOfPackage(0)).To(Equal(List{{0, 3}}))
+	})
+
+	It("finds siblings with core IDs repeating across clusters", func() {
+		topo := Successful(fakeSysfs(flatTopologyFixture(8,
+			func(cpu int) int { return cpu / 4 },
+			func(cpu int) int { return cpu % 4 })).Topology())
+		for cpu := range uint(8) {
+			Expect(topo.SiblingsOf(cpu)).To(Equal(List{{cpu, cpu}}))
+		}
+		Expect(topo.CPUsOfCluster(0, 1)).To(Equal(List{{4, 7}}))
+		Expect(topo.CPUsOfCore(0, 1)).To(Equal(List{{1, 1}, {5, 5}}))
+	})
+
+	It("handles older kernels", func() {
+		fs := fakeSysfs(sysfsFiles{
+			"devices/system/cpu/cpu1/topology/physical_package_id":  "0",
+			"devices/system/cpu/cpu1/topology/core_id":              "-1",
+			"devices/system/cpu/cpu1/topology/thread_siblings_list": "1",
+			"devices/system/cpu/cpu1/topology/core_siblings_list":   "0-1",
+		})
+		Expect(fs.CPUTopology(1)).To(Equal(&CPUTopology{
+			CPU:            1,
+			Package:        0,
+			Die:            -1,
+			Cluster:        -1,
+			Core:           -1,
+			ThreadSiblings: List{{1, 1}},
+			CoreSiblings:   List{{0, 1}},
+			DieCPUs:        List{{0, 1}},
+			PackageCPUs:    List{{0, 1}},
+		}))
+	})
+
+	It("reports errors", func() {
+		Expect(Sysfs(GinkgoT().TempDir()).Topology()).Error().To(MatchError(os.ErrNotExist))
+		Expect(fakeSysfs(topologyFixture(), sysfsFiles{
+			"devices/system/cpu/online": "0-8",
+		}).Topology()).Error().To(MatchError(os.ErrNotExist))
+		Expect(fakeSysfs(topologyFixture(), sysfsFiles{
+			"devices/system/cpu/cpu3/topology/core_id": "x",
+		}).Topology()).Error().To(HaveOccurred())
+		Expect(fakeSysfs(topologyFixture(), sysfsFiles{
+			"devices/system/cpu/cpu3/topology/die_id": "x",
+		}).Topology()).Error().To(HaveOccurred())
+		Expect(fakeSysfs(topologyFixture(), sysfsFiles{
+			"devices/system/cpu/cpu3/topology/thread_siblings_list": "x",
+		}).Topology()).Error().To(MatchError(ErrExpectedNumber))
+		Expect(fakeSysfs(topologyFixture(), sysfsFiles{
+			"devices/system/cpu/cpu3/topology/core_siblings_list": "x",
+		}).Topology()).Error().To(MatchError(ErrExpectedNumber))
+		Expect(fakeSysfs(topologyFixture(), sysfsFiles{
+			"devices/system/cpu/cpu3/topology/package_cpus_list": "x",
+		}).Topology()).Error().To(MatchError(ErrExpectedNumber))
+		Expect(fakeSysfs(topologyFixture(), sysfsFiles{
+			"devices/system/cpu/cpu3/topology/die_cpus_list": "x",
+		}).Topology()).Error().To(MatchError(ErrExpectedNumber))
+	})
+
+	It("discovers this system's topology", func() {
+		topo := Successful(DefaultSysfs.Topology())
+		Expect(topo.List()).To(Equal(Successful(DefaultSysfs.OnlineCPUs())))
+		for _, c := range topo.CPUs {
+			Expect(c.ThreadSiblings.Contains(c.CPU)).To(BeTrue())
+			Expect(c.PackageCPUs.Contains(c.CPU)).To(BeTrue())
+			Expect(topo.SiblingsOf(c.CPU)).To(Equal(c.ThreadSiblings.Overlap(topo.List())))
+			Expect(topo.CPUsOfPackage(c.Package).Contains(c.CPU)).To(BeTrue())
+		}
+	})
+
+})